@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ukpabik/CSYou/pkg/api/model"
+	"github.com/ukpabik/CSYou/pkg/shared"
+)
+
+// runDeadLetters implements `csyou dead-letters [flags] [redrive] [id...]`,
+// which lists dead letters or re-drives them through a running collector's
+// API.
+func runDeadLetters(args []string) {
+	fs := flag.NewFlagSet("dead-letters", flag.ExitOnError)
+	api := fs.String("api", fmt.Sprintf("http://%s:%s", shared.ADDRESS, shared.API_PORT), "collector API to talk to")
+	topic := fs.String("topic", "", "only dead letters from this topic")
+	all := fs.Bool("all", false, "list dead letters already re-driven too")
+	verbose := fs.Bool("v", false, "print each original message")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: csyou dead-letters [flags]                list dead letters")
+		fmt.Fprintln(fs.Output(), "       csyou dead-letters [flags] redrive [id...] re-drive them, all pending if no ids")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	query := url.Values{}
+	if *topic != "" {
+		query.Set("topic", *topic)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	if fs.Arg(0) == "redrive" {
+		for _, id := range fs.Args()[1:] {
+			query.Add("id", id)
+		}
+
+		var result struct {
+			Redriven int `json:"redriven"`
+		}
+		if err := callAPI(client, http.MethodPost, *api+"/dead-letters/redrive?"+query.Encode(), &result); err != nil {
+			log.Fatalf("re-drive failed: %v", err)
+		}
+		fmt.Printf("Re-drove %d dead letter(s)\n", result.Redriven)
+		return
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	if *all {
+		query.Set("all", "true")
+	}
+	var deadLetters []model.ClickHouseDeadLetter
+	if err := callAPI(client, http.MethodGet, *api+"/dead-letters/?"+query.Encode(), &deadLetters); err != nil {
+		log.Fatalf("listing dead letters failed: %v", err)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tTOPIC\tFAILED AT\tATTEMPTS\tREDRIVEN\tERROR")
+	for _, dl := range deadLetters {
+		failedAt := time.UnixMilli(dl.FailedAt).Format("2006-01-02 15:04:05")
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%v\t%s\n", dl.ID, dl.Topic, failedAt, dl.Attempts, dl.Redriven, dl.Error)
+		if *verbose {
+			fmt.Fprintf(tw, "\t%s\n", dl.Value)
+		}
+	}
+	tw.Flush()
+}
+
+// callAPI sends a request to the collector API and decodes the JSON response
+// into out.
+func callAPI(client *http.Client, method, target string, out any) error {
+	req, err := http.NewRequest(method, target, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API returned %s: %s", resp.Status, body)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -9,6 +9,7 @@ import (
 	"syscall"
 
 	"github.com/ukpabik/CSYou/pkg/api"
+	"github.com/ukpabik/CSYou/pkg/config"
 	"github.com/ukpabik/CSYou/pkg/db"
 	"github.com/ukpabik/CSYou/pkg/gsi"
 	"github.com/ukpabik/CSYou/pkg/kafka_io"
@@ -24,8 +25,11 @@ func setupGracefulShutdown() {
 		<-sigs
 		log.Println("Shutting down gracefully...")
 
-		// Close Kafka connections
-		kafka_io.CloseReaderAndWriters()
+		// Close the event bus, letting consumers store what's queued
+		kafka_io.CloseEventBus()
+
+		// Flush any raw GSI recordings
+		gsi.CloseRecorder()
 
 		// Close ClickHouse connection
 		db.CloseClickHouseConnection()
@@ -36,23 +40,26 @@ func setupGracefulShutdown() {
 }
 
 func main() {
-
-	// Initialize Redis client for hot queries
-	redis.InitializeRedisClient(fmt.Sprintf("%s:%d", shared.ADDRESS, shared.REDIS_PORT))
-
-	// Initialize ClickHouse Client
-	db.InitializeClickHouseClient(shared.ADDRESS, shared.CLICKHOUSE_PORT)
-
-	if err := db.CreateTables(); err != nil {
-		log.Fatalf("unable to create clickhouse tables: %v", err)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "replay":
+			runReplay(os.Args[2:])
+			return
+		case "simulate":
+			runSimulate(os.Args[2:])
+			return
+		case "dead-letters":
+			runDeadLetters(os.Args[2:])
+			return
+		}
 	}
 
-	// Initialize Kafka Reader and Writer, and ensure graceful shutdown
-	kafka_io.InitializeReaderAndWriter(shared.ADDRESS, shared.KAFKA_PORT)
+	initializeStores()
+
+	// Initialize the event bus, and ensure graceful shutdown
+	initializeEventBus()
 	setupGracefulShutdown()
-	// Run kafka reading in a goroutine
-	go kafka_io.ReadPlayerEventLoop()
-	go kafka_io.ReadKillEventLoop()
+	startConsumers()
 
 	// Listen for events from CS2 GSI
 	go func() {
@@ -67,3 +74,62 @@ func main() {
 		log.Fatalf("failed to start API server: %v", err)
 	}
 }
+
+// initializeStores connects to Redis and ClickHouse. Either may be missing,
+// in which case events aren't cached or stored there.
+func initializeStores() {
+	// Initialize Redis client for hot queries
+	redis.InitializeRedisClient(fmt.Sprintf("%s:%d", shared.ADDRESS, shared.REDIS_PORT))
+
+	// Initialize ClickHouse Client
+	db.InitializeClickHouseClient(shared.ADDRESS, shared.CLICKHOUSE_PORT)
+	if db.ClickHouseClient == nil {
+		log.Println("WARNING: running without ClickHouse, stats won't be stored")
+		return
+	}
+
+	if err := db.CreateTables(); err != nil {
+		log.Fatalf("unable to create clickhouse tables: %v", err)
+	}
+}
+
+// initializeEventBus sets up the event bus configured in config.json.
+func initializeEventBus() {
+	cfg, err := config.Load(config.DEFAULT_PATH)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	if err := kafka_io.InitializeEventBus(cfg.EventBus, shared.ADDRESS, shared.KAFKA_PORT); err != nil {
+		log.Fatalf("unable to initialize event bus: %v", err)
+	}
+}
+
+// startConsumers runs a consumer loop for each topic. With nowhere to store
+// events there is nothing to consume, so with a WAL they are kept for a run
+// that has.
+func startConsumers() {
+	if db.ClickHouseClient == nil && redis.RedisClient == nil {
+		log.Println("WARNING: neither Redis nor ClickHouse is available, events are only shown live")
+		return
+	}
+
+	go kafka_io.ReadPlayerEventLoop()
+	go kafka_io.ReadKillEventLoop()
+	go kafka_io.ReadDeathEventLoop()
+	go kafka_io.ReadAssistEventLoop()
+	go kafka_io.ReadMatchEventLoop()
+	go kafka_io.ReadRoundSummaryLoop()
+	go kafka_io.ReadBombEventLoop()
+	go kafka_io.ReadUtilityEventLoop()
+	go kafka_io.ReadDamageEventLoop()
+	go kafka_io.ReadClutchEventLoop()
+	go kafka_io.ReadMultiKillEventLoop()
+	go kafka_io.ReadBadgeEventLoop()
+	go kafka_io.ReadDuelEventLoop()
+
+	// Dead letters are kept in ClickHouse, so can't be stored without it
+	if db.ClickHouseClient != nil {
+		go kafka_io.ReadDeadLetterLoop()
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/ukpabik/CSYou/pkg/api"
+	"github.com/ukpabik/CSYou/pkg/db"
+	"github.com/ukpabik/CSYou/pkg/event_bus"
+	"github.com/ukpabik/CSYou/pkg/gsi"
+	"github.com/ukpabik/CSYou/pkg/kafka_io"
+	"github.com/ukpabik/CSYou/pkg/shared"
+)
+
+// replayOptions are the flags accepted by `csyou replay`.
+type replayOptions struct {
+	speed     float64
+	target    string
+	inProcess bool
+	token     string
+}
+
+// runReplay implements `csyou replay [flags] file...`. Each file is NDJSON of
+// gsi.RecordedPayload, optionally gzip'd, as written by the recorder.
+func runReplay(args []string) {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	opts := replayOptions{}
+	fs.Float64Var(&opts.speed, "speed", 1, "pacing multiplier: 1 is real-time, 10 is 10x, 0 is as fast as possible")
+	fs.StringVar(&opts.target, "target", fmt.Sprintf("http://127.0.0.1:%d", gsi.GSI_PORT), "GSI endpoint to POST payloads to")
+	fs.BoolVar(&opts.inProcess, "inprocess", false, "inject payloads into the GSI handlers in this process instead of POSTing")
+	fs.StringVar(&opts.token, "token", "", "auth token to send in place of the recorded one, which is redacted")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: csyou replay [flags] recording.ndjson[.gz] ...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	if err := replay(opts, fs.Args()); err != nil {
+		log.Fatalf("%v", err)
+	}
+}
+
+// replay sends the payloads in each file. With -inprocess, the bus and the
+// stores are closed before it returns, so what was replayed is stored even
+// if a file fails.
+func replay(opts replayOptions, paths []string) error {
+	// A collector stamps POSTed payloads with when they arrive, not when they
+	// were recorded
+	post := postPayload(opts.target)
+	send := func(body []byte, _ time.Time) error { return post(body) }
+	if opts.inProcess {
+		gsi.LoadConfig()
+		// Don't record the replay of a recording
+		gsi.RECORDER_CONFIG.Enabled = false
+
+		initializeEventBus()
+
+		// Nothing outside this process can consume an in-process bus
+		if _, ok := kafka_io.Bus.(*event_bus.MemoryBus); ok {
+			initializeStores()
+			defer db.CloseClickHouseConnection()
+			startConsumers()
+		}
+		// Deferred last so it runs first, draining consumers into the stores
+		defer kafka_io.CloseEventBus()
+
+		// Not served, but starts the log broadcaster so the GSI handlers'
+		// frontend logs are drained instead of filling the channel.
+		api.InitializeAPIServer(shared.ADDRESS, shared.API_PORT)
+
+		gsi.InitializeEventHandlers()
+		send = gsi.Inject
+	}
+
+	if opts.token != "" {
+		send = withToken(send, opts.token)
+	}
+
+	for _, path := range paths {
+		count, err := replayFile(path, opts.speed, send)
+		if err != nil {
+			return fmt.Errorf("replay of %s failed after %d payload(s): %w", path, count, err)
+		}
+		log.Printf("Replayed %d payload(s) from %s", count, path)
+	}
+	return nil
+}
+
+// postPayload returns a sender that POSTs each body to target.
+func postPayload(target string) func([]byte) error {
+	client := &http.Client{Timeout: 5 * time.Second}
+	return func(body []byte) error {
+		resp, err := client.Post(target, "application/json", bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		io.Copy(io.Discard, resp.Body)
+
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("GSI endpoint returned %s", resp.Status)
+		}
+		return nil
+	}
+}
+
+// withToken returns a sender that sets auth.token in each body before
+// passing it to send.
+func withToken(send func([]byte, time.Time) error, token string) func([]byte, time.Time) error {
+	return func(body []byte, receivedAt time.Time) error {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(body, &fields); err != nil {
+			return err
+		}
+
+		auth, err := json.Marshal(map[string]string{"token": token})
+		if err != nil {
+			return err
+		}
+		fields["auth"] = auth
+
+		if body, err = json.Marshal(fields); err != nil {
+			return err
+		}
+		return send(body, receivedAt)
+	}
+}
+
+// replayFile sends every payload in path along with when it was recorded, or
+// now if that is unknown, sleeping between payloads so the original gaps are
+// reproduced at the given speed.
+func replayFile(path string, speed float64, send func([]byte, time.Time) error) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	reader, err := maybeGunzip(file)
+	if err != nil {
+		return 0, err
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	count := 0
+	var lastTS int64
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		payload, err := parseRecordedLine(line)
+		if err != nil {
+			return count, fmt.Errorf("line %d: %w", count+1, err)
+		}
+
+		if speed > 0 && lastTS != 0 && payload.ReceivedAt > lastTS {
+			gap := time.Duration(payload.ReceivedAt-lastTS) * time.Millisecond
+			time.Sleep(time.Duration(float64(gap) / speed))
+		}
+		lastTS = payload.ReceivedAt
+
+		receivedAt := time.Now()
+		if payload.ReceivedAt != 0 {
+			receivedAt = time.UnixMilli(payload.ReceivedAt)
+		}
+		if err := send(payload.Body, receivedAt); err != nil {
+			log.Printf("failed to replay payload %d: %v", count+1, err)
+		}
+		count++
+	}
+
+	return count, scanner.Err()
+}
+
+// parseRecordedLine accepts a gsi.RecordedPayload line, or a bare GSI body in
+// which case provider.timestamp is used for pacing.
+func parseRecordedLine(line []byte) (*gsi.RecordedPayload, error) {
+	var payload gsi.RecordedPayload
+	if err := json.Unmarshal(line, &payload); err != nil {
+		return nil, err
+	}
+	if len(payload.Body) > 0 {
+		return &payload, nil
+	}
+
+	var bare struct {
+		Provider *struct {
+			Timestamp int64 `json:"timestamp"`
+		} `json:"provider"`
+	}
+	if err := json.Unmarshal(line, &bare); err != nil {
+		return nil, err
+	}
+	if bare.Provider != nil {
+		payload.ReceivedAt = bare.Provider.Timestamp * 1000
+	}
+	payload.Body = append(json.RawMessage(nil), line...)
+	return &payload, nil
+}
+
+// maybeGunzip wraps r in a gzip reader if it starts with the gzip magic bytes.
+func maybeGunzip(r io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(r)
+	magic, err := buffered.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		return gzip.NewReader(buffered)
+	}
+	return buffered, nil
+}
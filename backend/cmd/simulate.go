@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/ukpabik/CSYou/pkg/config"
+	"github.com/ukpabik/CSYou/pkg/gsi"
+	"github.com/ukpabik/CSYou/pkg/simulator"
+)
+
+// runSimulate implements `csyou simulate [flags]`, which plays simulated
+// matches against a collector's GSI port.
+func runSimulate(args []string) {
+	opts := simulator.DefaultOptions()
+
+	// Play as the configured player by default, since a collector with
+	// steam_id set drops payloads from anyone else
+	var playerIDs map[string]bool
+	if cfg, err := config.Load(config.DEFAULT_PATH); err == nil {
+		playerIDs = cfg.PlayerIDs()
+		if cfg.SteamID != "" {
+			opts.SteamID = cfg.SteamID
+		}
+	}
+
+	fs := flag.NewFlagSet("simulate", flag.ExitOnError)
+	fs.StringVar(&opts.Map, "map", opts.Map, "map name")
+	fs.IntVar(&opts.Rounds, "rounds", opts.Rounds, "max rounds in the match")
+	fs.IntVar(&opts.Players, "players", opts.Players, "players in the match (2-10)")
+	fs.DurationVar(&opts.Tick, "tick", opts.Tick, "game time between payloads")
+	fs.Float64Var(&opts.EventRate, "event-rate", opts.EventRate, "average combat events per second of live round time")
+	fs.StringVar(&opts.SteamID, "steamid", opts.SteamID, "SteamID of the simulated player")
+	fs.StringVar(&opts.AuthToken, "token", "", "GSI auth token to send")
+	fs.BoolVar(&opts.AllPlayers, "allplayers", false, "send allplayers and bomb blocks, as a spectator would receive")
+	fs.Int64Var(&opts.Seed, "seed", 0, "random seed (0 for a random match)")
+	speed := fs.Float64("speed", 1, "pacing multiplier: 1 is real-time, 10 is 10x, 0 is as fast as possible")
+	target := fs.String("target", fmt.Sprintf("http://127.0.0.1:%d", gsi.GSI_PORT), "GSI endpoint to POST payloads to")
+	clients := fs.Int("clients", 1, "number of clients to simulate concurrently, each with its own SteamID")
+	matches := fs.Int("matches", 1, "number of matches each client plays")
+	fs.Parse(args)
+
+	if *clients < 1 || *matches < 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	baseID, err := strconv.ParseUint(opts.SteamID, 10, 64)
+	if err != nil {
+		log.Fatalf("invalid steamid %q: %v", opts.SteamID, err)
+	}
+
+	if len(playerIDs) > 0 {
+		for c := 0; c < *clients; c++ {
+			steamID := strconv.FormatUint(baseID+uint64(c*10), 10)
+			if !playerIDs[steamID] {
+				log.Printf("WARNING: SteamID %s is not in config.json, a collector using it will drop its payloads", steamID)
+			}
+		}
+	}
+
+	send := postPayload(*target)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for c := 0; c < *clients; c++ {
+		clientOpts := opts
+		// Leave room for the other players of each client's match
+		clientOpts.SteamID = strconv.FormatUint(baseID+uint64(c*10), 10)
+		if opts.Seed != 0 {
+			clientOpts.Seed = opts.Seed + int64(c)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < *matches; i++ {
+				sent, err := simulator.Run(ctx, clientOpts, *speed, send)
+				if err != nil {
+					log.Printf("simulated match for %s failed after %d payload(s): %v", clientOpts.SteamID, sent, err)
+					return
+				}
+				log.Printf("Simulated match %d for %s: %d payload(s) sent", i+1, clientOpts.SteamID, sent)
+				if clientOpts.Seed != 0 {
+					clientOpts.Seed += int64(*clients)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -4,31 +4,53 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"regexp"
 	"strconv"
 
 	"github.com/ukpabik/CSYou/pkg/api/model"
 	"github.com/ukpabik/CSYou/pkg/db"
 )
 
-func GetPlayerEventsByParamsHandler(w http.ResponseWriter, r *http.Request) {
-	queryParams := r.URL.Query()
+// steamIDPattern is a Steam64 ID, the only form the steamid filter takes.
+var steamIDPattern = regexp.MustCompile(`^[0-9]{17}$`)
+
+// steamIDParam returns the steamid query parameter, which may be unset.
+func steamIDParam(queryParams url.Values) (string, error) {
+	steamID := queryParams.Get("steamid")
+	if steamID != "" && !steamIDPattern.MatchString(steamID) {
+		return "", fmt.Errorf("steamid must be a 17 digit Steam64 ID")
+	}
+	return steamID, nil
+}
 
-	matchId := queryParams.Get("match_id")
-	roundStr := queryParams.Get("round")
+// eventQueryOptions builds the match_id, round and steamid filters shared by
+// every event query.
+func eventQueryOptions(queryParams url.Values) ([]model.QueryOption, error) {
+	steamID, err := steamIDParam(queryParams)
+	if err != nil {
+		return nil, err
+	}
 
-	var roundInt int
-	if roundStr != "" {
-		if val, err := strconv.Atoi(roundStr); err == nil {
-			roundInt = val
-		}
+	options := []model.QueryOption{
+		model.WithMatchID(queryParams.Get("match_id")),
+		model.WithSteamID(steamID),
 	}
 
-	playerOptions := []model.QueryOption{
-		model.WithMatchID(matchId),
+	if roundStr := queryParams.Get("round"); roundStr != "" {
+		if roundInt, err := strconv.Atoi(roundStr); err == nil && roundInt != 0 {
+			options = append(options, model.WithRound(roundInt))
+		}
 	}
 
-	if roundInt != 0 {
-		playerOptions = append(playerOptions, model.WithRound(roundInt))
+	return options, nil
+}
+
+func GetPlayerEventsByParamsHandler(w http.ResponseWriter, r *http.Request) {
+	playerOptions, err := eventQueryOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
 	// Build config
@@ -50,24 +72,13 @@ func GetPlayerEventsByParamsHandler(w http.ResponseWriter, r *http.Request) {
 func GetKillEventsByParamsHandler(w http.ResponseWriter, r *http.Request) {
 	queryParams := r.URL.Query()
 
-	matchId := queryParams.Get("match_id")
 	headshot := queryParams.Get("headshot")
 	weaponName := queryParams.Get("weapon_name")
 
-	playerOptions := []model.QueryOption{
-		model.WithMatchID(matchId),
-	}
-	roundStr := queryParams.Get("round")
-
-	var roundInt int
-	if roundStr != "" {
-		if val, err := strconv.Atoi(roundStr); err == nil {
-			roundInt = val
-		}
-	}
-
-	if roundInt != 0 {
-		playerOptions = append(playerOptions, model.WithRound(roundInt))
+	playerOptions, err := eventQueryOptions(queryParams)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 	headshotBool, _ := strconv.ParseBool(headshot)
 	killOptions := []model.KillQueryOption{
@@ -90,6 +101,300 @@ func GetKillEventsByParamsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func GetDeathEventsByParamsHandler(w http.ResponseWriter, r *http.Request) {
+	options, err := eventQueryOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	paramConfig := model.NewClickHouseEventQueryConfig(options)
+
+	events, err := db.GetDeathEventsByParams(*paramConfig)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to get death events from db: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(events); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func GetAssistEventsByParamsHandler(w http.ResponseWriter, r *http.Request) {
+	options, err := eventQueryOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	paramConfig := model.NewClickHouseEventQueryConfig(options)
+
+	events, err := db.GetAssistEventsByParams(*paramConfig)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to get assist events from db: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(events); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func GetMatchesByParamsHandler(w http.ResponseWriter, r *http.Request) {
+	options, err := eventQueryOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	paramConfig := model.NewClickHouseEventQueryConfig(options)
+
+	matches, err := db.GetMatchesByParams(*paramConfig)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to get matches from db: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(matches); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func GetRoundSummariesByParamsHandler(w http.ResponseWriter, r *http.Request) {
+	options, err := eventQueryOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	paramConfig := model.NewClickHouseEventQueryConfig(options)
+
+	summaries, err := db.GetRoundSummariesByParams(*paramConfig)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to get round summaries from db: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(summaries); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func GetExposureByParamsHandler(w http.ResponseWriter, r *http.Request) {
+	options, err := eventQueryOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	paramConfig := model.NewClickHouseEventQueryConfig(options)
+
+	exposure, err := db.GetExposureByParams(*paramConfig)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to get exposure from db: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(exposure); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func GetDamageEventsByParamsHandler(w http.ResponseWriter, r *http.Request) {
+	options, err := eventQueryOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	paramConfig := model.NewClickHouseEventQueryConfig(options)
+
+	events, err := db.GetDamageEventsByParams(*paramConfig)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to get damage events from db: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(events); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func GetDamageStatsByParamsHandler(w http.ResponseWriter, r *http.Request) {
+	options, err := eventQueryOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	paramConfig := model.NewClickHouseEventQueryConfig(options)
+
+	stats, err := db.GetDamageStatsByParams(*paramConfig)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to get damage stats from db: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func GetEconomyByParamsHandler(w http.ResponseWriter, r *http.Request) {
+	options, err := eventQueryOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	paramConfig := model.NewClickHouseEventQueryConfig(options)
+
+	economy, err := db.GetEconomyByParams(*paramConfig)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to get economy from db: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(economy); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func GetClutchEventsByParamsHandler(w http.ResponseWriter, r *http.Request) {
+	options, err := eventQueryOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	paramConfig := model.NewClickHouseEventQueryConfig(options)
+
+	events, err := db.GetClutchEventsByParams(*paramConfig)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to get clutch events from db: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(events); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func GetClutchStatsByParamsHandler(w http.ResponseWriter, r *http.Request) {
+	options, err := eventQueryOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	paramConfig := model.NewClickHouseEventQueryConfig(options)
+
+	stats, err := db.GetClutchStatsByParams(*paramConfig)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to get clutch stats from db: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func GetMultiKillEventsByParamsHandler(w http.ResponseWriter, r *http.Request) {
+	options, err := eventQueryOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	paramConfig := model.NewClickHouseEventQueryConfig(options)
+
+	events, err := db.GetMultiKillEventsByParams(*paramConfig)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to get multi-kill events from db: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(events); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func GetBadgeTotalsByParamsHandler(w http.ResponseWriter, r *http.Request) {
+	options, err := eventQueryOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	paramConfig := model.NewClickHouseEventQueryConfig(options)
+
+	totals, err := db.GetBadgeTotalsByParams(*paramConfig)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to get badge totals from db: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(totals); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func GetBombEventsByParamsHandler(w http.ResponseWriter, r *http.Request) {
+	options, err := eventQueryOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	paramConfig := model.NewClickHouseEventQueryConfig(options)
+
+	events, err := db.GetBombEventsByParams(*paramConfig)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to get bomb events from db: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(events); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func GetUtilityEventsByParamsHandler(w http.ResponseWriter, r *http.Request) {
+	options, err := eventQueryOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	paramConfig := model.NewClickHouseEventQueryConfig(options)
+
+	events, err := db.GetUtilityEventsByParams(*paramConfig)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to get utility events from db: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(events); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
 func GetAllKillEventsHandler(w http.ResponseWriter, r *http.Request) {
 	events, err := db.GetAllKillEvents()
 	if err != nil {
@@ -116,3 +421,45 @@ func GetAllPlayerEventsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+func GetDuelEventsByParamsHandler(w http.ResponseWriter, r *http.Request) {
+	options, err := eventQueryOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	paramConfig := model.NewClickHouseEventQueryConfig(options)
+
+	events, err := db.GetDuelEventsByParams(*paramConfig)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to get duel events from db: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(events); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func GetDuelStatsByParamsHandler(w http.ResponseWriter, r *http.Request) {
+	options, err := eventQueryOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	paramConfig := model.NewClickHouseEventQueryConfig(options)
+
+	stats, err := db.GetDuelStatsByParams(*paramConfig)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to get duel stats from db: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
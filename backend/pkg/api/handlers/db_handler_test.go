@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestEventQueryOptionsValidatesSteamID(t *testing.T) {
+	for steamID, valid := range map[string]bool{
+		"":                             true,
+		"76561197960287930":            true,
+		"7656119796028793":             false,
+		"' OR 1=1 --":                  false,
+		"76561197960287930' OR 1=1 --": false,
+	} {
+		_, err := eventQueryOptions(url.Values{"steamid": {steamID}})
+		if (err == nil) != valid {
+			t.Errorf("steamid %q: got error %v, want valid = %v", steamID, err, valid)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/ukpabik/CSYou/pkg/db"
+	"github.com/ukpabik/CSYou/pkg/kafka_io"
+)
+
+// GetDeadLettersHandler lists dead letters not yet re-driven, or all of them
+// with all=true. topic and id narrow them down; id may be repeated.
+func GetDeadLettersHandler(w http.ResponseWriter, r *http.Request) {
+	queryParams := r.URL.Query()
+
+	deadLetters, err := db.GetDeadLetters(queryParams.Get("topic"), queryParams["id"], queryParams.Get("all") == "true")
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to get dead letters from db: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(deadLetters); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// RedriveDeadLettersHandler publishes dead letters back to their topics. With
+// no topic or id, every one not yet re-driven is sent.
+func RedriveDeadLettersHandler(w http.ResponseWriter, r *http.Request) {
+	queryParams := r.URL.Query()
+
+	redriven, err := kafka_io.RedriveDeadLetters(queryParams.Get("topic"), queryParams["id"])
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to re-drive dead letters (%d sent): %v", redriven, err), http.StatusInternalServerError)
+		return
+	}
+
+	type RedriveObject struct {
+		Redriven int `json:"redriven"`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(&RedriveObject{Redriven: redriven}); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ukpabik/CSYou/pkg/shared"
+)
+
+func GetAuthRejectionsHandler(w http.ResponseWriter, r *http.Request) {
+	total, rejections := shared.AuthRejections.Snapshot()
+
+	type AuthRejectionsObject struct {
+		Total      int64                  `json:"total"`
+		Rejections []shared.AuthRejection `json:"rejections"`
+	}
+
+	rejectionsObject := &AuthRejectionsObject{
+		Total:      total,
+		Rejections: rejections,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(rejectionsObject); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
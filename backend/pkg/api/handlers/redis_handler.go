@@ -8,8 +8,14 @@ import (
 )
 
 func GetAllRedisPlayerEventsHandler(w http.ResponseWriter, r *http.Request) {
+	steamID, err := steamIDParam(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	ctx := r.Context()
-	events, err := redis.GetAllPlayerEvents(ctx)
+	events, err := redis.GetAllPlayerEvents(ctx, steamID)
 	if err != nil {
 		http.Error(w, "Failed to get player events", http.StatusInternalServerError)
 		return
@@ -20,8 +26,14 @@ func GetAllRedisPlayerEventsHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func GetAllRedisKillEventsHandler(w http.ResponseWriter, r *http.Request) {
+	steamID, err := steamIDParam(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	ctx := r.Context()
-	events, err := redis.GetAllKillEvents(ctx)
+	events, err := redis.GetAllKillEvents(ctx, steamID)
 	if err != nil {
 		http.Error(w, "Failed to get kill events", http.StatusInternalServerError)
 		return
@@ -31,6 +43,60 @@ func GetAllRedisKillEventsHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(events)
 }
 
+func GetAllRedisDeathEventsHandler(w http.ResponseWriter, r *http.Request) {
+	steamID, err := steamIDParam(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	events, err := redis.GetAllDeathEvents(ctx, steamID)
+	if err != nil {
+		http.Error(w, "Failed to get death events", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(events)
+}
+
+func GetAllRedisAssistEventsHandler(w http.ResponseWriter, r *http.Request) {
+	steamID, err := steamIDParam(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	events, err := redis.GetAllAssistEvents(ctx, steamID)
+	if err != nil {
+		http.Error(w, "Failed to get assist events", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(events)
+}
+
+func GetAllRedisRoundSummariesHandler(w http.ResponseWriter, r *http.Request) {
+	steamID, err := steamIDParam(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	summaries, err := redis.GetAllRoundSummaries(ctx, steamID)
+	if err != nil {
+		http.Error(w, "Failed to get round summaries", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summaries)
+}
+
 func ClearCacheHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	err := redis.ClearCache(ctx)
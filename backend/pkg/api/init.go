@@ -88,6 +88,9 @@ func InitializeAPIServer(addr, port string) *chi.Mux {
 	chiRouter.Route("/redis", func(r chi.Router) {
 		r.Get("/player-events", handlers.GetAllRedisPlayerEventsHandler)
 		r.Get("/kill-events", handlers.GetAllRedisKillEventsHandler)
+		r.Get("/death-events", handlers.GetAllRedisDeathEventsHandler)
+		r.Get("/assist-events", handlers.GetAllRedisAssistEventsHandler)
+		r.Get("/round-summaries", handlers.GetAllRedisRoundSummariesHandler)
 		r.Get("/cache-size", handlers.GetCacheSizeHandler)
 		r.Delete("/clear", handlers.ClearCacheHandler)
 	})
@@ -97,6 +100,31 @@ func InitializeAPIServer(addr, port string) *chi.Mux {
 		r.Get("/player-events", handlers.GetAllPlayerEventsHandler)
 		r.Get("/player-events/params", handlers.GetPlayerEventsByParamsHandler)
 		r.Get("/kill-events/params", handlers.GetKillEventsByParamsHandler)
+		r.Get("/death-events", handlers.GetDeathEventsByParamsHandler)
+		r.Get("/assist-events", handlers.GetAssistEventsByParamsHandler)
+		r.Get("/matches", handlers.GetMatchesByParamsHandler)
+		r.Get("/round-summaries", handlers.GetRoundSummariesByParamsHandler)
+		r.Get("/exposure", handlers.GetExposureByParamsHandler)
+		r.Get("/economy", handlers.GetEconomyByParamsHandler)
+		r.Get("/damage-events", handlers.GetDamageEventsByParamsHandler)
+		r.Get("/damage", handlers.GetDamageStatsByParamsHandler)
+		r.Get("/clutch-events", handlers.GetClutchEventsByParamsHandler)
+		r.Get("/clutches", handlers.GetClutchStatsByParamsHandler)
+		r.Get("/multi-kills", handlers.GetMultiKillEventsByParamsHandler)
+		r.Get("/badges", handlers.GetBadgeTotalsByParamsHandler)
+		r.Get("/duel-events", handlers.GetDuelEventsByParamsHandler)
+		r.Get("/duels", handlers.GetDuelStatsByParamsHandler)
+		r.Get("/bomb-events", handlers.GetBombEventsByParamsHandler)
+		r.Get("/utility-events", handlers.GetUtilityEventsByParamsHandler)
+	})
+
+	chiRouter.Route("/gsi", func(r chi.Router) {
+		r.Get("/auth-rejections", handlers.GetAuthRejectionsHandler)
+	})
+
+	chiRouter.Route("/dead-letters", func(r chi.Router) {
+		r.Get("/", handlers.GetDeadLettersHandler)
+		r.Post("/redrive", handlers.RedriveDeadLettersHandler)
 	})
 
 	// WebSocket endpoint
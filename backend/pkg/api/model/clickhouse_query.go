@@ -1,6 +1,13 @@
 package model
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
+
+// quoteEscaper escapes a value for a ClickHouse string literal, so filter
+// values can't end the literal early.
+var quoteEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
 
 // Column Name, Value
 type QueryField[T any] struct {
@@ -22,7 +29,7 @@ func (q QueryField[T]) String() string {
 		if v == "" {
 			return ""
 		}
-		return fmt.Sprintf("%s = '%v'", q.Name, v)
+		return fmt.Sprintf("%s = '%s'", q.Name, quoteEscaper.Replace(v))
 	case bool:
 		// Only include if true, skip false
 		if !v {
@@ -39,6 +46,7 @@ func (q QueryField[T]) String() string {
 type ClickHouseEventQueryConfig struct {
 	Round   QueryField[int]
 	MatchID QueryField[string]
+	SteamID QueryField[string]
 }
 
 type ClickHouseKillEventQueryConfig struct {
@@ -53,6 +61,7 @@ func NewClickHouseEventQueryConfig(options []QueryOption) *ClickHouseEventQueryC
 	config := &ClickHouseEventQueryConfig{
 		Round:   QueryField[int]{Name: "round"},
 		MatchID: QueryField[string]{Name: "match_id"},
+		SteamID: QueryField[string]{Name: "steamid"},
 	}
 	for _, opt := range options {
 		opt(config)
@@ -65,6 +74,7 @@ func NewClickHouseKillEventQueryConfig(playerOptions []QueryOption, killOptions
 		ClickHouseEventQueryConfig: ClickHouseEventQueryConfig{
 			Round:   QueryField[int]{Name: "round"},
 			MatchID: QueryField[string]{Name: "match_id"},
+			SteamID: QueryField[string]{Name: "steamid"},
 		},
 		WeaponName:     QueryField[string]{Name: "weapon_name"},
 		WeaponHeadshot: QueryField[bool]{Name: "weapon_headshot"},
@@ -96,6 +106,12 @@ func WithMatchID(matchID string) QueryOption {
 	}
 }
 
+func WithSteamID(steamID string) QueryOption {
+	return func(c *ClickHouseEventQueryConfig) {
+		c.SteamID.Value = &steamID
+	}
+}
+
 func WithWeaponName(weaponName string) KillQueryOption {
 	return func(c *ClickHouseKillEventQueryConfig) {
 		c.WeaponName.Value = &weaponName
@@ -0,0 +1,17 @@
+package model
+
+import "testing"
+
+func TestQueryFieldEscapesStrings(t *testing.T) {
+	for value, want := range map[string]string{
+		"abc":          `match_id = 'abc'`,
+		"' OR 1=1 --":  `match_id = '\' OR 1=1 --'`,
+		`\' OR 1=1 --`: `match_id = '\\\' OR 1=1 --'`,
+		"":             "",
+	} {
+		field := QueryField[string]{Name: "match_id", Value: &value}
+		if got := field.String(); got != want {
+			t.Errorf("%q: got %s, want %s", value, got, want)
+		}
+	}
+}
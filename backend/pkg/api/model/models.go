@@ -3,6 +3,7 @@ package model
 type Log struct {
 	EventType string `json:"event_type"`
 	Time      string `json:"time"`
+	Data      any    `json:"data,omitempty"` // event details, for events the frontend shows live
 }
 
 type ClickHouseKillEvent struct {
@@ -13,6 +14,7 @@ type ClickHouseKillEvent struct {
 	SteamID string `ch:"steamid"`
 	Name    string `ch:"name"`
 	Mode    string `ch:"mode"`
+	EventID string `ch:"event_id"`
 
 	WeaponName     string `ch:"weapon_name"`
 	WeaponType     string `ch:"weapon_type"`
@@ -20,6 +22,7 @@ type ClickHouseKillEvent struct {
 	WeaponReserve  uint32 `ch:"weapon_reserve"`
 	WeaponSkin     string `ch:"weapon_skin"`
 	WeaponHeadshot bool   `ch:"weapon_headshot"`
+	HeadshotExact  bool   `ch:"headshot_exact"`
 
 	Timestamp int64 `ch:"timestamp"`
 }
@@ -32,12 +35,16 @@ type ClickHousePlayerEvent struct {
 	SteamID string `ch:"steamid"`
 	Name    string `ch:"name"`
 	Mode    string `ch:"mode"`
+	EventID string `ch:"event_id"`
 
 	Health      uint32 `ch:"health"`
 	Armor       uint32 `ch:"armor"`
 	Helmet      bool   `ch:"helmet"`
 	Money       uint32 `ch:"money"`
 	EquipValue  uint32 `ch:"equip_value"`
+	Flashed     uint32 `ch:"flashed"`
+	Smoked      uint32 `ch:"smoked"`
+	Burning     uint32 `ch:"burning"`
 	RoundKills  uint32 `ch:"round_kills"`
 	RoundKillHS uint32 `ch:"round_killhs"`
 	Kills       uint32 `ch:"kills"`
@@ -49,3 +56,329 @@ type ClickHousePlayerEvent struct {
 	EventTS int64  `ch:"event_timestamp"`
 	WinTeam string `ch:"win_team"`
 }
+
+type ClickHouseDeathEvent struct {
+	MatchId string `ch:"match_id"`
+	Round   uint32 `ch:"round"`
+	Map     string `ch:"map"`
+	Team    string `ch:"team"`
+	SteamID string `ch:"steamid"`
+	Name    string `ch:"name"`
+	Mode    string `ch:"mode"`
+	EventID string `ch:"event_id"`
+
+	RoundPhase  string  `ch:"round_phase"`
+	RoundClock  float64 `ch:"round_clock"`
+	WeaponName  string  `ch:"weapon_name"`
+	WeaponType  string  `ch:"weapon_type"`
+	EquipValue  uint32  `ch:"equip_value"`
+	BombPlanted bool    `ch:"bomb_planted"`
+
+	Timestamp int64 `ch:"timestamp"`
+}
+
+type ClickHouseAssistEvent struct {
+	MatchId string `ch:"match_id"`
+	Round   uint32 `ch:"round"`
+	Map     string `ch:"map"`
+	Team    string `ch:"team"`
+	SteamID string `ch:"steamid"`
+	Name    string `ch:"name"`
+	Mode    string `ch:"mode"`
+	EventID string `ch:"event_id"`
+
+	WeaponName  string `ch:"weapon_name"`
+	WeaponType  string `ch:"weapon_type"`
+	FlashAssist bool   `ch:"flash_assist"`
+
+	Timestamp int64 `ch:"timestamp"`
+}
+
+type ClickHouseMatch struct {
+	MatchId string `ch:"match_id"`
+	SteamID string `ch:"steamid"`
+	Map     string `ch:"map"`
+	Mode    string `ch:"mode"`
+	Team    string `ch:"team"`
+	State   string `ch:"state"`
+
+	JoinedInProgress bool `ch:"joined_in_progress"`
+
+	ScoreCT  uint32 `ch:"score_ct"`
+	ScoreT   uint32 `ch:"score_t"`
+	Rounds   uint32 `ch:"rounds"`
+	Winner   string `ch:"winner"`
+	Result   string `ch:"result"`
+	Overtime bool   `ch:"overtime"`
+
+	StartedAt int64 `ch:"started_at"`
+	EndedAt   int64 `ch:"ended_at"`
+	Duration  int64 `ch:"duration"`
+}
+
+type ClickHouseRoundSummary struct {
+	MatchId string `ch:"match_id"`
+	Round   uint32 `ch:"round"`
+	Map     string `ch:"map"`
+	Team    string `ch:"team"`
+	SteamID string `ch:"steamid"`
+	Name    string `ch:"name"`
+	Mode    string `ch:"mode"`
+	EventID string `ch:"event_id"`
+
+	StartMoney uint32 `ch:"start_money"`
+	Spent      uint32 `ch:"spent"`
+	EquipValue uint32 `ch:"equip_value"`
+	BuyType    string `ch:"buy_type"`
+
+	Losses         uint32 `ch:"losses"`
+	LossBonus      uint32 `ch:"loss_bonus"`
+	MinNextMoney   uint32 `ch:"min_next_money"`
+	CanFullBuyNext bool   `ch:"can_full_buy_next"`
+	BadBuy         string `ch:"bad_buy"`
+
+	Kills      uint32 `ch:"kills"`
+	Headshots  uint32 `ch:"headshots"`
+	HealthLost uint32 `ch:"health_lost"`
+	Survived   bool   `ch:"survived"`
+
+	UtilityBought uint32 `ch:"utility_bought"`
+	UtilityThrown uint32 `ch:"utility_thrown"`
+	UtilityLost   uint32 `ch:"utility_lost"`
+	UtilitySpent  uint32 `ch:"utility_spent"`
+
+	FlashedSeconds float64 `ch:"flashed_seconds"`
+	PeakFlashed    uint32  `ch:"peak_flashed"`
+	SmokedSeconds  float64 `ch:"smoked_seconds"`
+	PeakSmoked     uint32  `ch:"peak_smoked"`
+	BurningSeconds float64 `ch:"burning_seconds"`
+	PeakBurning    uint32  `ch:"peak_burning"`
+	DiedFlashed    bool    `ch:"died_flashed"`
+
+	TimelineAt     []int64  `ch:"timeline_at"`
+	TimelineHealth []uint32 `ch:"timeline_health"`
+	TimelineArmor  []uint32 `ch:"timeline_armor"`
+
+	WinTeam      string `ch:"win_team"`
+	WinCondition string `ch:"win_condition"`
+	BombOutcome  string `ch:"bomb_outcome"`
+
+	Partial   bool  `ch:"partial"`
+	Timestamp int64 `ch:"timestamp"`
+}
+
+type ClickHouseBombEvent struct {
+	MatchId string `ch:"match_id"`
+	Round   uint32 `ch:"round"`
+	Map     string `ch:"map"`
+	Team    string `ch:"team"`
+	SteamID string `ch:"steamid"`
+	Name    string `ch:"name"`
+	Mode    string `ch:"mode"`
+	EventID string `ch:"event_id"`
+
+	Action   string `ch:"action"`
+	Actor    string `ch:"actor"`
+	ByPlayer bool   `ch:"by_player"`
+	Site     string `ch:"site"`
+	Position string `ch:"position"`
+
+	Timestamp int64 `ch:"timestamp"`
+}
+
+type ClickHouseUtilityEvent struct {
+	MatchId string `ch:"match_id"`
+	Round   uint32 `ch:"round"`
+	Map     string `ch:"map"`
+	Team    string `ch:"team"`
+	SteamID string `ch:"steamid"`
+	Name    string `ch:"name"`
+	Mode    string `ch:"mode"`
+	EventID string `ch:"event_id"`
+
+	Grenade string `ch:"grenade"`
+	Action  string `ch:"action"`
+	Count   uint32 `ch:"count"`
+	Value   uint32 `ch:"value"`
+
+	Timestamp int64 `ch:"timestamp"`
+}
+
+// ClickHouseExposure is a player's flash, smoke and burn exposure aggregated
+// over their round summaries.
+type ClickHouseExposure struct {
+	SteamID string `ch:"steamid"`
+	Rounds  uint64 `ch:"rounds"`
+
+	FlashedSeconds float64 `ch:"total_flashed_seconds"`
+	AvgPeakFlashed float64 `ch:"avg_peak_flashed"`
+	MaxPeakFlashed uint32  `ch:"max_peak_flashed"`
+	SmokedSeconds  float64 `ch:"total_smoked_seconds"`
+	BurningSeconds float64 `ch:"total_burning_seconds"`
+
+	Deaths        uint64 `ch:"deaths"`
+	DeathsFlashed uint64 `ch:"deaths_flashed"`
+}
+
+type ClickHouseDamageEvent struct {
+	MatchId string `ch:"match_id"`
+	Round   uint32 `ch:"round"`
+	Map     string `ch:"map"`
+	Team    string `ch:"team"`
+	SteamID string `ch:"steamid"`
+	Name    string `ch:"name"`
+	Mode    string `ch:"mode"`
+	EventID string `ch:"event_id"`
+
+	HealthDamage uint32 `ch:"health_damage"`
+	ArmorDamage  uint32 `ch:"armor_damage"`
+	Health       uint32 `ch:"health"`
+	Armor        uint32 `ch:"armor"`
+	Fatal        bool   `ch:"fatal"`
+
+	FightStart  bool   `ch:"fight_start"`
+	FightHealth uint32 `ch:"fight_health"`
+	FightHits   uint32 `ch:"fight_hits"`
+
+	RoundPhase string  `ch:"round_phase"`
+	RoundClock float64 `ch:"round_clock"`
+
+	Timestamp int64 `ch:"timestamp"`
+}
+
+// ClickHouseEconomy is a player's results on one buy type, aggregated over
+// their round summaries.
+type ClickHouseEconomy struct {
+	SteamID string  `ch:"steamid"`
+	BuyType string  `ch:"buy_type"`
+	Rounds  uint64  `ch:"rounds"`
+	Wins    uint64  `ch:"wins"`
+	WinRate float64 `ch:"win_rate"`
+	Kills   uint64  `ch:"total_kills"`
+	Deaths  uint64  `ch:"deaths"`
+	KD      float64 `ch:"kd"`
+}
+
+// ClickHouseDamageStats is a player's damage taken aggregated over their
+// damage events.
+type ClickHouseDamageStats struct {
+	SteamID      string `ch:"steamid"`
+	Hits         uint64 `ch:"hits"`
+	HealthDamage uint64 `ch:"total_health_damage"`
+	ArmorDamage  uint64 `ch:"total_armor_damage"`
+
+	Deaths               uint64  `ch:"deaths"`
+	AvgDamageBeforeDeath float64 `ch:"avg_damage_before_death"` // in the fight that killed them
+	AvgHitsBeforeDeath   float64 `ch:"avg_hits_before_death"`
+
+	Fights     uint64  `ch:"fights"`
+	FightsHurt uint64  `ch:"fights_hurt"` // fights entered below full health
+	HurtRate   float64 `ch:"hurt_rate"`
+}
+
+type ClickHouseClutchEvent struct {
+	MatchId string `ch:"match_id"`
+	Round   uint32 `ch:"round"`
+	Map     string `ch:"map"`
+	Team    string `ch:"team"`
+	SteamID string `ch:"steamid"`
+	Name    string `ch:"name"`
+	Mode    string `ch:"mode"`
+	EventID string `ch:"event_id"`
+
+	Action  string `ch:"action"`
+	Vs      uint32 `ch:"vs"`
+	Enemies int32  `ch:"enemies"`
+	Kills   uint32 `ch:"kills"`
+
+	Timestamp int64 `ch:"timestamp"`
+}
+
+// ClickHouseClutchStats is a player's clutch results for one 1vX, map and
+// side.
+type ClickHouseClutchStats struct {
+	SteamID  string  `ch:"steamid"`
+	Vs       uint32  `ch:"vs"`
+	Map      string  `ch:"map"`
+	Team     string  `ch:"team"`
+	Clutches uint64  `ch:"clutches"`
+	Wins     uint64  `ch:"wins"`
+	WinRate  float64 `ch:"win_rate"`
+	AvgKills float64 `ch:"avg_kills"`
+}
+
+type ClickHouseMultiKillEvent struct {
+	MatchId string `ch:"match_id"`
+	Round   uint32 `ch:"round"`
+	Map     string `ch:"map"`
+	Team    string `ch:"team"`
+	SteamID string `ch:"steamid"`
+	Name    string `ch:"name"`
+	Mode    string `ch:"mode"`
+	EventID string `ch:"event_id"`
+
+	Kills     uint32 `ch:"kills"`
+	Headshots uint32 `ch:"headshots"`
+	Label     string `ch:"label"`
+
+	Timestamp int64 `ch:"timestamp"`
+}
+
+// ClickHouseBadgeTotal is how many times a player earned a badge or
+// multi-kill.
+type ClickHouseBadgeTotal struct {
+	SteamID string `ch:"steamid"`
+	Badge   string `ch:"badge"`
+	Total   uint64 `ch:"total"`
+}
+
+type ClickHouseDuelEvent struct {
+	MatchId string `ch:"match_id"`
+	Round   uint32 `ch:"round"`
+	Map     string `ch:"map"`
+	Team    string `ch:"team"`
+	SteamID string `ch:"steamid"`
+	Name    string `ch:"name"`
+	Mode    string `ch:"mode"`
+	EventID string `ch:"event_id"`
+
+	Kind    string `ch:"kind"`
+	Opening bool   `ch:"opening"`
+	Trade   bool   `ch:"trade"`
+	Tracked bool   `ch:"tracked"`
+	Window  int64  `ch:"trade_window"`
+
+	Timestamp int64 `ch:"timestamp"`
+}
+
+// ClickHouseDuelStats is a player's opening duels and trades for one map and
+// side.
+type ClickHouseDuelStats struct {
+	SteamID        string  `ch:"steamid"`
+	Map            string  `ch:"map"`
+	Team           string  `ch:"team"`
+	OpeningDuels   uint64  `ch:"opening_duels"`
+	OpeningWins    uint64  `ch:"opening_wins"`
+	OpeningWinRate float64 `ch:"opening_win_rate"`
+	Deaths         uint64  `ch:"total_deaths"`
+	TradedDeaths   uint64  `ch:"traded_deaths"`
+	TradedRate     float64 `ch:"traded_rate"`
+	Kills          uint64  `ch:"total_kills"`
+	TradeKills     uint64  `ch:"trade_kills"`
+	TradeKillRate  float64 `ch:"trade_kill_rate"`
+}
+
+type ClickHouseDeadLetter struct {
+	ID       string `ch:"id"`
+	Topic    string `ch:"topic"`
+	Group    string `ch:"consumer_group"`
+	Key      string `ch:"message_key"`
+	Value    string `ch:"message_value"`
+	Offset   int64  `ch:"message_offset"`
+	Error    string `ch:"error"`
+	Attempts uint32 `ch:"attempts"`
+	FailedAt int64  `ch:"failed_at"`
+
+	Redriven  bool  `ch:"redriven"`
+	UpdatedAt int64 `ch:"updated_at"`
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// DEFAULT_PATH is where the collector looks for its config, relative to backend/.
+const DEFAULT_PATH = "../config.json"
+
+// Config struct for reading config.json
+type Config struct {
+	SteamID string `json:"steam_id"`
+
+	// AuthToken is a single expected GSI auth token. AuthTokens allows one
+	// token per teammate; both may be set and are merged.
+	AuthToken  string      `json:"auth_token"`
+	AuthTokens []AuthToken `json:"auth_tokens"`
+
+	// SpectatorMode records every player in the allplayers block (spectating
+	// or GOTV) instead of only steam_id.
+	SpectatorMode bool `json:"spectator_mode"`
+
+	Recorder RecorderConfig `json:"recorder"`
+
+	// TradeWindow is how many seconds a teammate has to avenge a death for it
+	// to count as traded. Defaults to 5.
+	TradeWindow int `json:"trade_window_seconds"`
+
+	EventBus EventBusConfig `json:"event_bus"`
+}
+
+// EventBusConfig picks how events get from the GSI handlers to storage.
+type EventBusConfig struct {
+	Backend string `json:"backend"` // kafka or memory, defaults to kafka
+	WALDir  string `json:"wal_dir"` // memory only: log events here so they survive a restart
+}
+
+// RecorderConfig controls recording of raw GSI payloads to disk.
+type RecorderConfig struct {
+	Enabled bool   `json:"enabled"`
+	Dir     string `json:"dir"`         // defaults to ../recordings
+	MaxMB   int    `json:"max_file_mb"` // rotate after this many compressed MB, defaults to 64
+}
+
+// AuthToken is a GSI auth token and a label for whoever owns it. If SteamID
+// is set, the token is only accepted from that player's client.
+type AuthToken struct {
+	Token   string `json:"token"`
+	Owner   string `json:"owner"`
+	SteamID string `json:"steam_id"`
+}
+
+// Load reads and parses the config file at path.
+func Load(path string) (*Config, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var config Config
+	if err := json.NewDecoder(file).Decode(&config); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return &config, nil
+}
+
+// Tokens returns every configured auth token, with empty tokens dropped. The
+// single auth_token is bound to steam_id, if set.
+func (c *Config) Tokens() []AuthToken {
+	var tokens []AuthToken
+	if c.AuthToken != "" {
+		tokens = append(tokens, AuthToken{Token: c.AuthToken, Owner: c.SteamID, SteamID: c.SteamID})
+	}
+	for _, t := range c.AuthTokens {
+		if t.Token != "" {
+			tokens = append(tokens, t)
+		}
+	}
+	return tokens
+}
+
+// PlayerIDs returns the set of SteamIDs configured through steam_id or
+// auth_tokens.
+func (c *Config) PlayerIDs() map[string]bool {
+	ids := make(map[string]bool)
+	if c.SteamID != "" {
+		ids[c.SteamID] = true
+	}
+	for _, t := range c.AuthTokens {
+		if t.SteamID != "" {
+			ids[t.SteamID] = true
+		}
+	}
+	return ids
+}
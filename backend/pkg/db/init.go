@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -12,6 +13,9 @@ import (
 
 var ClickHouseClient driver.Conn
 
+// ErrNoClient is returned when ClickHouse isn't connected.
+var ErrNoClient = errors.New("clickhouse client is not initialized")
+
 func InitializeClickHouseClient(addr string, port int) {
 	conn, err := clickhouse.Open(&clickhouse.Options{
 		Addr: []string{fmt.Sprintf("%s:%d", addr, port)},
@@ -53,6 +57,9 @@ func InitializeClickHouseClient(addr string, port int) {
 }
 
 func CloseClickHouseConnection() {
+	if ClickHouseClient == nil {
+		return
+	}
 	if err := ClickHouseClient.Close(); err != nil {
 		log.Printf("unable to close clickhouse client: %v", err)
 	}
@@ -5,43 +5,67 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
+	"github.com/ClickHouse/clickhouse-go/v2"
 	"github.com/ukpabik/CSYou/pkg/api/model"
 	"github.com/ukpabik/CSYou/pkg/shared"
 )
 
 const (
-	killEventTableName   = "cs2_kill_events"
-	playerEventTableName = "cs2_player_events"
+	killEventTableName      = "cs2_kill_events"
+	playerEventTableName    = "cs2_player_events"
+	deathEventTableName     = "cs2_death_events"
+	assistEventTableName    = "cs2_assist_events"
+	matchTableName          = "cs2_matches"
+	roundSummaryTableName   = "cs2_round_summaries"
+	bombEventTableName      = "cs2_bomb_events"
+	utilityEventTableName   = "cs2_utility_events"
+	damageEventTableName    = "cs2_damage_events"
+	clutchEventTableName    = "cs2_clutch_events"
+	multiKillEventTableName = "cs2_multi_kill_events"
+	badgeEventTableName     = "cs2_badge_events"
+	duelEventTableName      = "cs2_duel_events"
+	deadLetterTableName     = "cs2_dead_letters"
 )
 
+// eventSortingKey is the ORDER BY of the event tables. ReplacingMergeTree
+// keeps one row per key, so it holds only what identifies an event: a
+// redelivered or replayed copy shares its event ID but not necessarily its
+// timestamp.
+const eventSortingKey = "match_id, steamid, event_id"
+
+// whereClause joins the set query fields into a WHERE clause, or returns ""
+// if none are set.
+func whereClause(fields []fmt.Stringer) string {
+	var conditions []string
+	for _, f := range fields {
+		if s := f.String(); s != "" {
+			conditions = append(conditions, s)
+		}
+	}
+
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
 // GetPlayerEventsByParams retrieves all player events for given params.
 func GetPlayerEventsByParams(config model.ClickHouseEventQueryConfig) ([]model.ClickHousePlayerEvent, error) {
 	if ClickHouseClient == nil {
-		return nil, fmt.Errorf("clickhouse client is not initialized")
+		return nil, ErrNoClient
 	}
 
 	ctx := context.Background()
 	var events []model.ClickHousePlayerEvent
 
-	query := fmt.Sprintf("SELECT * FROM %s", playerEventTableName)
-
-	var conditions []string
-	fields := []fmt.Stringer{
+	query := fmt.Sprintf("SELECT * FROM %s FINAL", playerEventTableName)
+	query += whereClause([]fmt.Stringer{
 		config.MatchID,
 		config.Round,
-	}
-
-	for _, f := range fields {
-		if s := f.String(); s != "" {
-			conditions = append(conditions, s)
-		}
-	}
-
-	// Append WHERE clause
-	if len(conditions) > 0 {
-		query = fmt.Sprintf("%s WHERE %s", query, strings.Join(conditions, " AND "))
-	}
+		config.SteamID,
+	})
 
 	// Execute query
 	err := ClickHouseClient.Select(ctx, &events, query)
@@ -54,33 +78,21 @@ func GetPlayerEventsByParams(config model.ClickHouseEventQueryConfig) ([]model.C
 
 func GetKillEventsByParams(config model.ClickHouseKillEventQueryConfig) ([]model.ClickHouseKillEvent, error) {
 	if ClickHouseClient == nil {
-		return nil, fmt.Errorf("clickhouse client is not initialized")
+		return nil, ErrNoClient
 	}
 
 	ctx := context.Background()
 	var events []model.ClickHouseKillEvent
 
 	// Base query
-	query := fmt.Sprintf("SELECT * FROM %s", killEventTableName)
-
-	var conditions []string
-	fields := []fmt.Stringer{
+	query := fmt.Sprintf("SELECT * FROM %s FINAL", killEventTableName)
+	query += whereClause([]fmt.Stringer{
 		config.Round,
 		config.MatchID,
+		config.SteamID,
 		config.WeaponName,
 		config.WeaponHeadshot,
-	}
-
-	for _, f := range fields {
-		if s := f.String(); s != "" {
-			conditions = append(conditions, s)
-		}
-	}
-
-	// Append WHERE clause
-	if len(conditions) > 0 {
-		query = fmt.Sprintf("%s WHERE %s", query, strings.Join(conditions, " AND "))
-	}
+	})
 
 	log.Printf("QUERY: %s", query)
 
@@ -97,11 +109,11 @@ func GetKillEventsByParams(config model.ClickHouseKillEventQueryConfig) ([]model
 // GetAllKillEvents retrieves all kill events across all matches from ClickHouse.
 func GetAllKillEvents() ([]model.ClickHouseKillEvent, error) {
 	if ClickHouseClient == nil {
-		return nil, fmt.Errorf("clickhouse client is not initialized")
+		return nil, ErrNoClient
 	}
 	context := context.Background()
 	var events []model.ClickHouseKillEvent
-	query := fmt.Sprintf("SELECT * FROM %s", killEventTableName)
+	query := fmt.Sprintf("SELECT * FROM %s FINAL", killEventTableName)
 
 	err := ClickHouseClient.Select(context, &events, query)
 	if err != nil {
@@ -115,11 +127,11 @@ func GetAllKillEvents() ([]model.ClickHouseKillEvent, error) {
 // GetAllPlayerEvents retrieves all player events across all matches from ClickHouse.
 func GetAllPlayerEvents() ([]model.ClickHousePlayerEvent, error) {
 	if ClickHouseClient == nil {
-		return nil, fmt.Errorf("clickhouse client is not initialized")
+		return nil, ErrNoClient
 	}
 	context := context.Background()
 	var events []model.ClickHousePlayerEvent
-	query := fmt.Sprintf("SELECT * FROM %s", playerEventTableName)
+	query := fmt.Sprintf("SELECT * FROM %s FINAL", playerEventTableName)
 	err := ClickHouseClient.Select(context, &events, query)
 	if err != nil {
 		fmt.Println(err)
@@ -128,203 +140,2073 @@ func GetAllPlayerEvents() ([]model.ClickHousePlayerEvent, error) {
 	return events, nil
 }
 
-// InsertKillEvents inserts multiple kill events using batch operation
-func InsertKillEvents(killEvents []shared.RedisKillEvent) error {
+// GetDeathEventsByParams retrieves all death events for given params.
+func GetDeathEventsByParams(config model.ClickHouseEventQueryConfig) ([]model.ClickHouseDeathEvent, error) {
 	if ClickHouseClient == nil {
-		return fmt.Errorf("clickhouse client is not initialized")
+		return nil, ErrNoClient
 	}
 
-	if len(killEvents) == 0 {
-		return nil
+	ctx := context.Background()
+	var events []model.ClickHouseDeathEvent
+
+	query := fmt.Sprintf("SELECT * FROM %s FINAL", deathEventTableName)
+	query += whereClause([]fmt.Stringer{
+		config.MatchID,
+		config.Round,
+		config.SteamID,
+	})
+
+	if err := ClickHouseClient.Select(ctx, &events, query); err != nil {
+		return nil, fmt.Errorf("failed to execute clickhouse query: %v", err)
+	}
+
+	return events, nil
+}
+
+// GetAssistEventsByParams retrieves all assist events for given params.
+func GetAssistEventsByParams(config model.ClickHouseEventQueryConfig) ([]model.ClickHouseAssistEvent, error) {
+	if ClickHouseClient == nil {
+		return nil, ErrNoClient
 	}
 
 	ctx := context.Background()
+	var events []model.ClickHouseAssistEvent
 
-	// Prepare batch insert
-	batch, err := ClickHouseClient.PrepareBatch(ctx, fmt.Sprintf(`
-        INSERT INTO %s (
-            match_id, round, map, team, steamid, name, mode,
-            weapon_name, weapon_type, weapon_ammo, weapon_reserve, weapon_skin, weapon_headshot,
-            timestamp
-        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-    `, killEventTableName))
+	query := fmt.Sprintf("SELECT * FROM %s FINAL", assistEventTableName)
+	query += whereClause([]fmt.Stringer{
+		config.MatchID,
+		config.Round,
+		config.SteamID,
+	})
 
-	if err != nil {
-		return fmt.Errorf("unable to prepare batch statement: %v", err)
+	if err := ClickHouseClient.Select(ctx, &events, query); err != nil {
+		return nil, fmt.Errorf("failed to execute clickhouse query: %v", err)
 	}
 
-	// Append all kill events to batch
-	for _, event := range killEvents {
-		err = batch.Append(
-			event.MatchID,
-			event.Round,
-			event.Map,
-			event.Team,
-			event.SteamID,
-			event.Name,
-			event.Mode,
-			event.ActiveGun.Name,
-			event.ActiveGun.Type,
-			event.ActiveGun.Ammo,
-			event.ActiveGun.Reserve,
-			event.ActiveGun.Skin,
-			event.ActiveGun.Headshot,
-			event.Timestamp,
-		)
-		if err != nil {
-			return fmt.Errorf("failed to append kill event to batch: %v", err)
-		}
+	return events, nil
+}
+
+// GetMatchesByParams retrieves the latest state of every match for given
+// params. Rounds are ignored, matches span all of them.
+func GetMatchesByParams(config model.ClickHouseEventQueryConfig) ([]model.ClickHouseMatch, error) {
+	if ClickHouseClient == nil {
+		return nil, ErrNoClient
 	}
 
-	// Execute batch
-	if err := batch.Send(); err != nil {
-		return fmt.Errorf("failed to execute batch insert for kill events: %v", err)
+	ctx := context.Background()
+	var matches []model.ClickHouseMatch
+
+	query := fmt.Sprintf("SELECT * FROM %s FINAL", matchTableName)
+	query += whereClause([]fmt.Stringer{
+		config.MatchID,
+		config.SteamID,
+	})
+	query += " ORDER BY started_at DESC"
+
+	if err := ClickHouseClient.Select(ctx, &matches, query); err != nil {
+		return nil, fmt.Errorf("failed to execute clickhouse query: %v", err)
 	}
 
-	return nil
+	return matches, nil
 }
 
-// InsertKillEvent inserts a single kill event
-func InsertKillEvent(killEvent *shared.RedisKillEvent) error {
-	return InsertKillEvents([]shared.RedisKillEvent{*killEvent})
+// GetRoundSummariesByParams retrieves all round summaries for given params.
+func GetRoundSummariesByParams(config model.ClickHouseEventQueryConfig) ([]model.ClickHouseRoundSummary, error) {
+	if ClickHouseClient == nil {
+		return nil, ErrNoClient
+	}
+
+	ctx := context.Background()
+	var summaries []model.ClickHouseRoundSummary
+
+	query := fmt.Sprintf("SELECT * FROM %s FINAL", roundSummaryTableName)
+	query += whereClause([]fmt.Stringer{
+		config.MatchID,
+		config.Round,
+		config.SteamID,
+	})
+	query += " ORDER BY match_id, round"
+
+	if err := ClickHouseClient.Select(ctx, &summaries, query); err != nil {
+		return nil, fmt.Errorf("failed to execute clickhouse query: %v", err)
+	}
+
+	return summaries, nil
 }
 
-// InsertPlayerEvents inserts multiple player events using batch operation
-func InsertPlayerEvents(playerEvents []shared.RedisPlayerEvent) error {
+// GetEconomyByParams aggregates win rate and K/D per player and buy type from
+// the round summaries matching the given params.
+func GetEconomyByParams(config model.ClickHouseEventQueryConfig) ([]model.ClickHouseEconomy, error) {
 	if ClickHouseClient == nil {
-		return fmt.Errorf("clickhouse client is not initialized")
+		return nil, ErrNoClient
 	}
 
-	if len(playerEvents) == 0 {
-		return nil
+	ctx := context.Background()
+	var economy []model.ClickHouseEconomy
+
+	query := fmt.Sprintf(`
+        SELECT
+            steamid,
+            buy_type,
+            count() AS rounds,
+            countIf(win_team = team) AS wins,
+            wins / rounds AS win_rate,
+            sum(kills) AS total_kills,
+            countIf(NOT survived) AS deaths,
+            if(deaths = 0, total_kills, total_kills / deaths) AS kd
+        FROM %s FINAL`, roundSummaryTableName)
+	query += whereClause([]fmt.Stringer{
+		config.MatchID,
+		config.Round,
+		config.SteamID,
+	})
+	// Rounds from before buy classification have no buy type
+	query += " GROUP BY steamid, buy_type HAVING buy_type != '' ORDER BY steamid, buy_type"
+
+	if err := ClickHouseClient.Select(ctx, &economy, query); err != nil {
+		return nil, fmt.Errorf("failed to execute clickhouse query: %v", err)
+	}
+
+	return economy, nil
+}
+
+// GetBombEventsByParams retrieves all bomb events for given params.
+func GetBombEventsByParams(config model.ClickHouseEventQueryConfig) ([]model.ClickHouseBombEvent, error) {
+	if ClickHouseClient == nil {
+		return nil, ErrNoClient
 	}
 
 	ctx := context.Background()
+	var events []model.ClickHouseBombEvent
 
-	// Prepare batch insert
-	batch, err := ClickHouseClient.PrepareBatch(ctx, fmt.Sprintf(`
-        INSERT INTO %s (
-            match_id, round, map, team, steamid, name, mode,
-            health, armor, helmet, money, equip_value,
-            round_kills, round_killhs,
-            kills, assists, deaths, mvps, score,
-            event_timestamp, win_team
-        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-    `, playerEventTableName))
+	query := fmt.Sprintf("SELECT * FROM %s FINAL", bombEventTableName)
+	query += whereClause([]fmt.Stringer{
+		config.MatchID,
+		config.Round,
+		config.SteamID,
+	})
 
-	if err != nil {
-		return fmt.Errorf("unable to prepare batch statement: %v", err)
+	if err := ClickHouseClient.Select(ctx, &events, query); err != nil {
+		return nil, fmt.Errorf("failed to execute clickhouse query: %v", err)
 	}
 
-	// Append all player events to batch
-	for _, event := range playerEvents {
-		err = batch.Append(
-			event.MatchID,
-			event.Round,
-			event.Map,
-			event.Team,
-			event.SteamID,
-			event.Name,
-			event.Mode,
-			event.Health,
-			event.Armor,
-			event.Helmet,
-			event.Money,
-			event.EquipValue,
-			event.RoundKills,
-			event.RoundKillHS,
-			event.Kills,
-			event.Assists,
-			event.Deaths,
-			event.MVPs,
-			event.Score,
-			event.EventTS,
-			event.WinTeam,
-		)
-		if err != nil {
-			return fmt.Errorf("failed to append player event to batch: %v", err)
-		}
+	return events, nil
+}
+
+// GetUtilityEventsByParams retrieves all utility events for given params.
+func GetUtilityEventsByParams(config model.ClickHouseEventQueryConfig) ([]model.ClickHouseUtilityEvent, error) {
+	if ClickHouseClient == nil {
+		return nil, ErrNoClient
 	}
 
-	// Execute batch
-	if err := batch.Send(); err != nil {
-		return fmt.Errorf("failed to execute batch insert for player events: %v", err)
+	ctx := context.Background()
+	var events []model.ClickHouseUtilityEvent
+
+	query := fmt.Sprintf("SELECT * FROM %s FINAL", utilityEventTableName)
+	query += whereClause([]fmt.Stringer{
+		config.MatchID,
+		config.Round,
+		config.SteamID,
+	})
+
+	if err := ClickHouseClient.Select(ctx, &events, query); err != nil {
+		return nil, fmt.Errorf("failed to execute clickhouse query: %v", err)
 	}
 
-	return nil
+	return events, nil
 }
 
-// InsertPlayerEvent inserts a single player event
-func InsertPlayerEvent(playerEvent *shared.RedisPlayerEvent) error {
-	return InsertPlayerEvents([]shared.RedisPlayerEvent{*playerEvent})
+// GetExposureByParams aggregates flash, smoke and burn exposure per player
+// from the round summaries matching the given params.
+func GetExposureByParams(config model.ClickHouseEventQueryConfig) ([]model.ClickHouseExposure, error) {
+	if ClickHouseClient == nil {
+		return nil, ErrNoClient
+	}
+
+	ctx := context.Background()
+	var exposure []model.ClickHouseExposure
+
+	query := fmt.Sprintf(`
+        SELECT
+            steamid,
+            count() AS rounds,
+            sum(flashed_seconds) AS total_flashed_seconds,
+            avg(peak_flashed) AS avg_peak_flashed,
+            max(peak_flashed) AS max_peak_flashed,
+            sum(smoked_seconds) AS total_smoked_seconds,
+            sum(burning_seconds) AS total_burning_seconds,
+            countIf(NOT survived) AS deaths,
+            countIf(died_flashed) AS deaths_flashed
+        FROM %s FINAL`, roundSummaryTableName)
+	query += whereClause([]fmt.Stringer{
+		config.MatchID,
+		config.Round,
+		config.SteamID,
+	})
+	query += " GROUP BY steamid ORDER BY steamid"
+
+	if err := ClickHouseClient.Select(ctx, &exposure, query); err != nil {
+		return nil, fmt.Errorf("failed to execute clickhouse query: %v", err)
+	}
+
+	return exposure, nil
 }
 
-// CreateTables creates the necessary ClickHouse tables
-func CreateTables() error {
+// GetDamageEventsByParams retrieves all damage events for given params.
+func GetDamageEventsByParams(config model.ClickHouseEventQueryConfig) ([]model.ClickHouseDamageEvent, error) {
 	if ClickHouseClient == nil {
-		return fmt.Errorf("clickhouse client is not initialized")
+		return nil, ErrNoClient
 	}
 
 	ctx := context.Background()
+	var events []model.ClickHouseDamageEvent
 
-	// Create kill events table
-	killEventSchema := fmt.Sprintf(`
-        CREATE TABLE IF NOT EXISTS %s (
-            match_id String,
-            round UInt32,
-            map String,
-            team String,
-            steamid String,
-            name String,
-            mode String,
-            weapon_name String,
-            weapon_type String,
-            weapon_ammo UInt32,
-            weapon_reserve UInt32,
-            weapon_skin String,
-            weapon_headshot Bool,
-            timestamp Int64
-        ) ENGINE = MergeTree()
-        ORDER BY (match_id, timestamp)
-        PARTITION BY toDate(fromUnixTimestamp(timestamp))
-    `, killEventTableName)
+	query := fmt.Sprintf("SELECT * FROM %s FINAL", damageEventTableName)
+	query += whereClause([]fmt.Stringer{
+		config.MatchID,
+		config.Round,
+		config.SteamID,
+	})
+	query += " ORDER BY match_id, timestamp"
 
-	if err := ClickHouseClient.Exec(ctx, killEventSchema); err != nil {
-		return fmt.Errorf("failed to create kill events table: %v", err)
+	if err := ClickHouseClient.Select(ctx, &events, query); err != nil {
+		return nil, fmt.Errorf("failed to execute clickhouse query: %v", err)
 	}
 
-	// Create player events table
-	playerEventSchema := fmt.Sprintf(`
-        CREATE TABLE IF NOT EXISTS %s (
-            match_id String,
-            round UInt32,
-            map String,
-            team String,
-            steamid String,
-            name String,
-            mode String,
-            health UInt32,
-            armor UInt32,
-            helmet Bool,
-            money UInt32,
-            equip_value UInt32,
-            round_kills UInt32,
-            round_killhs UInt32,
-            kills UInt32,
-            assists UInt32,
-            deaths UInt32,
-            mvps UInt32,
-            score UInt32,
-            event_timestamp Int64,
-            win_team String
-        ) ENGINE = MergeTree()
-        ORDER BY (match_id, event_timestamp)
-        PARTITION BY toDate(fromUnixTimestamp(event_timestamp))
-    `, playerEventTableName)
+	return events, nil
+}
 
-	if err := ClickHouseClient.Exec(ctx, playerEventSchema); err != nil {
-		return fmt.Errorf("failed to create player events table: %v", err)
+// GetDamageStatsByParams aggregates damage taken per player from the damage
+// events matching the given params: how much damage the fight that killed
+// them took, and how often they went into a fight already hurt.
+func GetDamageStatsByParams(config model.ClickHouseEventQueryConfig) ([]model.ClickHouseDamageStats, error) {
+	if ClickHouseClient == nil {
+		return nil, ErrNoClient
 	}
 
-	return nil
+	ctx := context.Background()
+	var stats []model.ClickHouseDamageStats
+
+	query := fmt.Sprintf(`
+        SELECT
+            steamid,
+            count() AS hits,
+            sum(health_damage) AS total_health_damage,
+            sum(armor_damage) AS total_armor_damage,
+            countIf(fatal) AS deaths,
+            ifNotFinite(avgIf(fight_health, fatal), 0) AS avg_damage_before_death,
+            ifNotFinite(avgIf(fight_hits, fatal), 0) AS avg_hits_before_death,
+            countIf(fight_start) AS fights,
+            countIf(fight_start AND fight_health < 100) AS fights_hurt,
+            if(fights = 0, 0, fights_hurt / fights) AS hurt_rate
+        FROM %s FINAL`, damageEventTableName)
+	query += whereClause([]fmt.Stringer{
+		config.MatchID,
+		config.Round,
+		config.SteamID,
+	})
+	query += " GROUP BY steamid ORDER BY steamid"
+
+	if err := ClickHouseClient.Select(ctx, &stats, query); err != nil {
+		return nil, fmt.Errorf("failed to execute clickhouse query: %v", err)
+	}
+
+	return stats, nil
+}
+
+// GetClutchEventsByParams retrieves all clutch events for given params.
+func GetClutchEventsByParams(config model.ClickHouseEventQueryConfig) ([]model.ClickHouseClutchEvent, error) {
+	if ClickHouseClient == nil {
+		return nil, ErrNoClient
+	}
+
+	ctx := context.Background()
+	var events []model.ClickHouseClutchEvent
+
+	query := fmt.Sprintf("SELECT * FROM %s FINAL", clutchEventTableName)
+	query += whereClause([]fmt.Stringer{
+		config.MatchID,
+		config.Round,
+		config.SteamID,
+	})
+	query += " ORDER BY match_id, timestamp"
+
+	if err := ClickHouseClient.Select(ctx, &events, query); err != nil {
+		return nil, fmt.Errorf("failed to execute clickhouse query: %v", err)
+	}
+
+	return events, nil
+}
+
+// GetClutchStatsByParams aggregates clutch win rates per player, 1vX, map
+// and side from the clutch outcomes matching the given params.
+func GetClutchStatsByParams(config model.ClickHouseEventQueryConfig) ([]model.ClickHouseClutchStats, error) {
+	if ClickHouseClient == nil {
+		return nil, ErrNoClient
+	}
+
+	ctx := context.Background()
+	var stats []model.ClickHouseClutchStats
+
+	query := fmt.Sprintf(`
+        SELECT
+            steamid,
+            vs,
+            map,
+            team,
+            count() AS clutches,
+            countIf(action = '%s') AS wins,
+            wins / clutches AS win_rate,
+            avg(kills) AS avg_kills
+        FROM %s FINAL`, shared.CLUTCH_WON, clutchEventTableName)
+	query += whereClause([]fmt.Stringer{
+		config.MatchID,
+		config.Round,
+		config.SteamID,
+		outcomeCondition{},
+	})
+	query += " GROUP BY steamid, vs, map, team ORDER BY steamid, vs, map, team"
+
+	if err := ClickHouseClient.Select(ctx, &stats, query); err != nil {
+		return nil, fmt.Errorf("failed to execute clickhouse query: %v", err)
+	}
+
+	return stats, nil
+}
+
+// outcomeCondition limits clutch events to outcomes.
+type outcomeCondition struct{}
+
+func (outcomeCondition) String() string {
+	return fmt.Sprintf("action IN ('%s', '%s')", shared.CLUTCH_WON, shared.CLUTCH_LOST)
+}
+
+// GetMultiKillEventsByParams retrieves all multi-kill events for given params.
+func GetMultiKillEventsByParams(config model.ClickHouseEventQueryConfig) ([]model.ClickHouseMultiKillEvent, error) {
+	if ClickHouseClient == nil {
+		return nil, ErrNoClient
+	}
+
+	ctx := context.Background()
+	var events []model.ClickHouseMultiKillEvent
+
+	query := fmt.Sprintf("SELECT * FROM %s FINAL", multiKillEventTableName)
+	query += whereClause([]fmt.Stringer{
+		config.MatchID,
+		config.Round,
+		config.SteamID,
+	})
+	query += " ORDER BY match_id, round"
+
+	if err := ClickHouseClient.Select(ctx, &events, query); err != nil {
+		return nil, fmt.Errorf("failed to execute clickhouse query: %v", err)
+	}
+
+	return events, nil
+}
+
+// GetBadgeTotalsByParams counts each player's badges and multi-kills over the
+// rounds matching the given params.
+func GetBadgeTotalsByParams(config model.ClickHouseEventQueryConfig) ([]model.ClickHouseBadgeTotal, error) {
+	if ClickHouseClient == nil {
+		return nil, ErrNoClient
+	}
+
+	ctx := context.Background()
+	var totals []model.ClickHouseBadgeTotal
+
+	where := whereClause([]fmt.Stringer{
+		config.MatchID,
+		config.Round,
+		config.SteamID,
+	})
+	// Aces are both a badge and a multi-kill, so only the badge counts them
+	query := fmt.Sprintf(`
+        SELECT steamid, badge, total FROM (
+            SELECT steamid, badge, sum(count) AS total
+            FROM %s FINAL%s
+            GROUP BY steamid, badge
+            UNION ALL
+            SELECT steamid, label AS badge, count() AS total
+            FROM %s FINAL%s
+            GROUP BY steamid, label
+            HAVING label != '%s'
+        )
+        ORDER BY steamid, badge`,
+		badgeEventTableName, where, multiKillEventTableName, where, shared.BADGE_ACE)
+
+	if err := ClickHouseClient.Select(ctx, &totals, query); err != nil {
+		return nil, fmt.Errorf("failed to execute clickhouse query: %v", err)
+	}
+
+	return totals, nil
+}
+
+// GetDuelEventsByParams retrieves all duel events for given params.
+func GetDuelEventsByParams(config model.ClickHouseEventQueryConfig) ([]model.ClickHouseDuelEvent, error) {
+	if ClickHouseClient == nil {
+		return nil, ErrNoClient
+	}
+
+	ctx := context.Background()
+	var events []model.ClickHouseDuelEvent
+
+	query := fmt.Sprintf("SELECT * FROM %s FINAL", duelEventTableName)
+	query += whereClause([]fmt.Stringer{
+		config.MatchID,
+		config.Round,
+		config.SteamID,
+	})
+	query += " ORDER BY match_id, timestamp"
+
+	if err := ClickHouseClient.Select(ctx, &events, query); err != nil {
+		return nil, fmt.Errorf("failed to execute clickhouse query: %v", err)
+	}
+
+	return events, nil
+}
+
+// GetDuelStatsByParams aggregates opening duel win rates and trade rates per
+// player, map and side from the tracked duel events matching the given params.
+func GetDuelStatsByParams(config model.ClickHouseEventQueryConfig) ([]model.ClickHouseDuelStats, error) {
+	if ClickHouseClient == nil {
+		return nil, ErrNoClient
+	}
+
+	ctx := context.Background()
+	var stats []model.ClickHouseDuelStats
+
+	query := fmt.Sprintf(`
+        SELECT
+            steamid,
+            map,
+            team,
+            countIf(opening) AS opening_duels,
+            countIf(opening AND kind = '%s') AS opening_wins,
+            if(opening_duels = 0, 0, opening_wins / opening_duels) AS opening_win_rate,
+            countIf(kind = '%s') AS total_deaths,
+            countIf(kind = '%s' AND trade) AS traded_deaths,
+            if(total_deaths = 0, 0, traded_deaths / total_deaths) AS traded_rate,
+            countIf(kind = '%s') AS total_kills,
+            countIf(kind = '%s' AND trade) AS trade_kills,
+            if(total_kills = 0, 0, trade_kills / total_kills) AS trade_kill_rate
+        FROM %s FINAL`,
+		shared.DUEL_KILL,
+		shared.DUEL_DEATH, shared.DUEL_DEATH,
+		shared.DUEL_KILL, shared.DUEL_KILL,
+		duelEventTableName)
+	query += whereClause([]fmt.Stringer{
+		config.MatchID,
+		config.Round,
+		config.SteamID,
+		trackedCondition{},
+	})
+	query += " GROUP BY steamid, map, team ORDER BY steamid, map, team"
+
+	if err := ClickHouseClient.Select(ctx, &stats, query); err != nil {
+		return nil, fmt.Errorf("failed to execute clickhouse query: %v", err)
+	}
+
+	return stats, nil
+}
+
+// trackedCondition limits duel events to those seen with alive counts.
+type trackedCondition struct{}
+
+func (trackedCondition) String() string {
+	return "tracked"
+}
+
+// GetDeadLetters retrieves dead letters, oldest first. topic and ids narrow
+// them down if set; re-driven ones are left out unless includeRedriven.
+func GetDeadLetters(topic string, ids []string, includeRedriven bool) ([]model.ClickHouseDeadLetter, error) {
+	if ClickHouseClient == nil {
+		return nil, ErrNoClient
+	}
+
+	ctx := context.Background()
+	var deadLetters []model.ClickHouseDeadLetter
+
+	var conditions []string
+	var args []any
+	if topic != "" {
+		conditions = append(conditions, "topic = ?")
+		args = append(args, topic)
+	}
+	if len(ids) > 0 {
+		conditions = append(conditions, "has(?, id)")
+		args = append(args, ids)
+	}
+	if !includeRedriven {
+		conditions = append(conditions, "NOT redriven")
+	}
+
+	// FINAL, so only the latest version of a re-driven one is seen
+	query := fmt.Sprintf("SELECT * FROM %s FINAL", deadLetterTableName)
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY failed_at"
+
+	if err := ClickHouseClient.Select(ctx, &deadLetters, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to execute clickhouse query: %v", err)
+	}
+
+	return deadLetters, nil
+}
+
+// MarkDeadLettersRedriven records that the dead letters with the given ids
+// were published again.
+func MarkDeadLettersRedriven(ids []string) error {
+	if ClickHouseClient == nil {
+		return ErrNoClient
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+
+	// A newer version of each row replaces the old one when parts merge
+	query := fmt.Sprintf(`
+        INSERT INTO %s
+        SELECT
+            id, topic, consumer_group, message_key, message_value, message_offset,
+            error, attempts, failed_at,
+            true AS redriven,
+            ? AS updated_at
+        FROM %s FINAL
+        WHERE has(?, id)`, deadLetterTableName, deadLetterTableName)
+
+	if err := ClickHouseClient.Exec(ctx, query, time.Now().UnixMilli(), ids); err != nil {
+		return fmt.Errorf("failed to mark dead letters re-driven: %v", err)
+	}
+
+	return nil
+}
+
+// InsertKillEvents inserts multiple kill events using batch operation
+func InsertKillEvents(killEvents []shared.RedisKillEvent) error {
+	if ClickHouseClient == nil {
+		return ErrNoClient
+	}
+
+	if len(killEvents) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+
+	// Prepare batch insert
+	batch, err := ClickHouseClient.PrepareBatch(ctx, fmt.Sprintf(`
+        INSERT INTO %s (
+            match_id, round, map, team, steamid, name, mode, event_id,
+            weapon_name, weapon_type, weapon_ammo, weapon_reserve, weapon_skin, weapon_headshot,
+            headshot_exact, timestamp
+        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `, killEventTableName))
+
+	if err != nil {
+		return fmt.Errorf("unable to prepare batch statement: %v", err)
+	}
+
+	// Append all kill events to batch
+	for _, event := range killEvents {
+		err = batch.Append(
+			event.MatchID,
+			event.Round,
+			event.Map,
+			event.Team,
+			event.SteamID,
+			event.Name,
+			event.Mode,
+			event.EventID,
+			event.ActiveGun.Name,
+			event.ActiveGun.Type,
+			event.ActiveGun.Ammo,
+			event.ActiveGun.Reserve,
+			event.ActiveGun.Skin,
+			event.ActiveGun.Headshot,
+			event.HeadshotExact,
+			event.Timestamp,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to append kill event to batch: %v", err)
+		}
+	}
+
+	// Execute batch
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("failed to execute batch insert for kill events: %v", err)
+	}
+
+	return nil
+}
+
+// InsertKillEvent inserts a single kill event
+func InsertKillEvent(killEvent *shared.RedisKillEvent) error {
+	return InsertKillEvents([]shared.RedisKillEvent{*killEvent})
+}
+
+// InsertPlayerEvents inserts multiple player events using batch operation
+func InsertPlayerEvents(playerEvents []shared.RedisPlayerEvent) error {
+	if ClickHouseClient == nil {
+		return ErrNoClient
+	}
+
+	if len(playerEvents) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+
+	// Prepare batch insert
+	batch, err := ClickHouseClient.PrepareBatch(ctx, fmt.Sprintf(`
+        INSERT INTO %s (
+            match_id, round, map, team, steamid, name, mode, event_id,
+            health, armor, helmet, money, equip_value,
+            flashed, smoked, burning,
+            round_kills, round_killhs,
+            kills, assists, deaths, mvps, score,
+            event_timestamp, win_team
+        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `, playerEventTableName))
+
+	if err != nil {
+		return fmt.Errorf("unable to prepare batch statement: %v", err)
+	}
+
+	// Append all player events to batch
+	for _, event := range playerEvents {
+		err = batch.Append(
+			event.MatchID,
+			event.Round,
+			event.Map,
+			event.Team,
+			event.SteamID,
+			event.Name,
+			event.Mode,
+			event.EventID,
+			event.Health,
+			event.Armor,
+			event.Helmet,
+			event.Money,
+			event.EquipValue,
+			event.Flashed,
+			event.Smoked,
+			event.Burning,
+			event.RoundKills,
+			event.RoundKillHS,
+			event.Kills,
+			event.Assists,
+			event.Deaths,
+			event.MVPs,
+			event.Score,
+			event.EventTS,
+			event.WinTeam,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to append player event to batch: %v", err)
+		}
+	}
+
+	// Execute batch
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("failed to execute batch insert for player events: %v", err)
+	}
+
+	return nil
+}
+
+// InsertPlayerEvent inserts a single player event
+func InsertPlayerEvent(playerEvent *shared.RedisPlayerEvent) error {
+	return InsertPlayerEvents([]shared.RedisPlayerEvent{*playerEvent})
+}
+
+// InsertDeathEvents inserts multiple death events using batch operation
+func InsertDeathEvents(deathEvents []shared.RedisDeathEvent) error {
+	if ClickHouseClient == nil {
+		return ErrNoClient
+	}
+
+	if len(deathEvents) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+
+	// Prepare batch insert
+	batch, err := ClickHouseClient.PrepareBatch(ctx, fmt.Sprintf(`
+        INSERT INTO %s (
+            match_id, round, map, team, steamid, name, mode, event_id,
+            round_phase, round_clock, weapon_name, weapon_type, equip_value, bomb_planted,
+            timestamp
+        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `, deathEventTableName))
+
+	if err != nil {
+		return fmt.Errorf("unable to prepare batch statement: %v", err)
+	}
+
+	// Append all death events to batch
+	for _, event := range deathEvents {
+		err = batch.Append(
+			event.MatchID,
+			event.Round,
+			event.Map,
+			event.Team,
+			event.SteamID,
+			event.Name,
+			event.Mode,
+			event.EventID,
+			event.RoundPhase,
+			event.RoundClock,
+			event.WeaponName,
+			event.WeaponType,
+			event.EquipValue,
+			event.BombPlanted,
+			event.Timestamp,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to append death event to batch: %v", err)
+		}
+	}
+
+	// Execute batch
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("failed to execute batch insert for death events: %v", err)
+	}
+
+	return nil
+}
+
+// InsertDeathEvent inserts a single death event
+func InsertDeathEvent(deathEvent *shared.RedisDeathEvent) error {
+	return InsertDeathEvents([]shared.RedisDeathEvent{*deathEvent})
+}
+
+// InsertAssistEvents inserts multiple assist events using batch operation
+func InsertAssistEvents(assistEvents []shared.RedisAssistEvent) error {
+	if ClickHouseClient == nil {
+		return ErrNoClient
+	}
+
+	if len(assistEvents) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+
+	// Prepare batch insert
+	batch, err := ClickHouseClient.PrepareBatch(ctx, fmt.Sprintf(`
+        INSERT INTO %s (
+            match_id, round, map, team, steamid, name, mode, event_id,
+            weapon_name, weapon_type, flash_assist,
+            timestamp
+        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `, assistEventTableName))
+
+	if err != nil {
+		return fmt.Errorf("unable to prepare batch statement: %v", err)
+	}
+
+	// Append all assist events to batch
+	for _, event := range assistEvents {
+		err = batch.Append(
+			event.MatchID,
+			event.Round,
+			event.Map,
+			event.Team,
+			event.SteamID,
+			event.Name,
+			event.Mode,
+			event.EventID,
+			event.WeaponName,
+			event.WeaponType,
+			event.FlashAssist,
+			event.Timestamp,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to append assist event to batch: %v", err)
+		}
+	}
+
+	// Execute batch
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("failed to execute batch insert for assist events: %v", err)
+	}
+
+	return nil
+}
+
+// InsertAssistEvent inserts a single assist event
+func InsertAssistEvent(assistEvent *shared.RedisAssistEvent) error {
+	return InsertAssistEvents([]shared.RedisAssistEvent{*assistEvent})
+}
+
+// InsertMatchEvents inserts multiple match lifecycle events using batch
+// operation. Each one replaces the previous row for its match.
+func InsertMatchEvents(matchEvents []shared.MatchEvent) error {
+	if ClickHouseClient == nil {
+		return ErrNoClient
+	}
+
+	if len(matchEvents) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+
+	// Prepare batch insert
+	batch, err := ClickHouseClient.PrepareBatch(ctx, fmt.Sprintf(`
+        INSERT INTO %s (
+            match_id, steamid, map, mode, team, state, joined_in_progress,
+            score_ct, score_t, rounds, winner, result, overtime,
+            started_at, ended_at, duration
+        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `, matchTableName))
+
+	if err != nil {
+		return fmt.Errorf("unable to prepare batch statement: %v", err)
+	}
+
+	// Append all match events to batch
+	for _, event := range matchEvents {
+		err = batch.Append(
+			event.MatchID,
+			event.SteamID,
+			event.Map,
+			event.Mode,
+			event.Team,
+			event.State,
+			event.JoinedInProgress,
+			event.ScoreCT,
+			event.ScoreT,
+			event.Rounds,
+			event.Winner,
+			event.Result,
+			event.Overtime,
+			event.StartedAt,
+			event.EndedAt,
+			event.Duration,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to append match event to batch: %v", err)
+		}
+	}
+
+	// Execute batch
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("failed to execute batch insert for match events: %v", err)
+	}
+
+	return nil
+}
+
+// InsertMatchEvent inserts a single match lifecycle event
+func InsertMatchEvent(matchEvent *shared.MatchEvent) error {
+	return InsertMatchEvents([]shared.MatchEvent{*matchEvent})
+}
+
+// InsertRoundSummaries inserts multiple round summaries using batch operation
+func InsertRoundSummaries(summaries []shared.RoundSummary) error {
+	if ClickHouseClient == nil {
+		return ErrNoClient
+	}
+
+	if len(summaries) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+
+	// Prepare batch insert
+	batch, err := ClickHouseClient.PrepareBatch(ctx, fmt.Sprintf(`
+        INSERT INTO %s (
+            match_id, round, map, team, steamid, name, mode, event_id,
+            start_money, spent, equip_value, buy_type,
+            losses, loss_bonus, min_next_money, can_full_buy_next, bad_buy,
+            kills, headshots, health_lost, survived,
+            utility_bought, utility_thrown, utility_lost, utility_spent,
+            flashed_seconds, peak_flashed, smoked_seconds, peak_smoked,
+            burning_seconds, peak_burning, died_flashed,
+            timeline_at, timeline_health, timeline_armor,
+            win_team, win_condition, bomb_outcome,
+            partial, timestamp
+        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `, roundSummaryTableName))
+
+	if err != nil {
+		return fmt.Errorf("unable to prepare batch statement: %v", err)
+	}
+
+	// Append all round summaries to batch
+	for _, summary := range summaries {
+		err = batch.Append(
+			summary.MatchID,
+			summary.Round,
+			summary.Map,
+			summary.Team,
+			summary.SteamID,
+			summary.Name,
+			summary.Mode,
+			summary.EventID,
+			summary.StartMoney,
+			summary.Spent,
+			summary.EquipValue,
+			summary.BuyType,
+			summary.Losses,
+			summary.LossBonus,
+			summary.MinNextMoney,
+			summary.CanFullBuyNext,
+			summary.BadBuy,
+			summary.Kills,
+			summary.Headshots,
+			summary.HealthLost,
+			summary.Survived,
+			summary.UtilityBought,
+			summary.UtilityThrown,
+			summary.UtilityLost,
+			summary.UtilitySpent,
+			summary.FlashedSeconds,
+			summary.PeakFlashed,
+			summary.SmokedSeconds,
+			summary.PeakSmoked,
+			summary.BurningSeconds,
+			summary.PeakBurning,
+			summary.DiedFlashed,
+			summary.TimelineAt,
+			summary.TimelineHealth,
+			summary.TimelineArmor,
+			summary.WinTeam,
+			summary.WinCondition,
+			summary.BombOutcome,
+			summary.Partial,
+			summary.Timestamp,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to append round summary to batch: %v", err)
+		}
+	}
+
+	// Execute batch
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("failed to execute batch insert for round summaries: %v", err)
+	}
+
+	return nil
+}
+
+// InsertRoundSummary inserts a single round summary
+func InsertRoundSummary(summary *shared.RoundSummary) error {
+	return InsertRoundSummaries([]shared.RoundSummary{*summary})
+}
+
+// InsertBombEvents inserts multiple bomb events using batch operation
+func InsertBombEvents(bombEvents []shared.BombEvent) error {
+	if ClickHouseClient == nil {
+		return ErrNoClient
+	}
+
+	if len(bombEvents) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+
+	// Prepare batch insert
+	batch, err := ClickHouseClient.PrepareBatch(ctx, fmt.Sprintf(`
+        INSERT INTO %s (
+            match_id, round, map, team, steamid, name, mode, event_id,
+            action, actor, by_player, site, position,
+            timestamp
+        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `, bombEventTableName))
+
+	if err != nil {
+		return fmt.Errorf("unable to prepare batch statement: %v", err)
+	}
+
+	// Append all bomb events to batch
+	for _, event := range bombEvents {
+		err = batch.Append(
+			event.MatchID,
+			event.Round,
+			event.Map,
+			event.Team,
+			event.SteamID,
+			event.Name,
+			event.Mode,
+			event.EventID,
+			event.Action,
+			event.Actor,
+			event.ByPlayer,
+			event.Site,
+			event.Position,
+			event.Timestamp,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to append bomb event to batch: %v", err)
+		}
+	}
+
+	// Execute batch
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("failed to execute batch insert for bomb events: %v", err)
+	}
+
+	return nil
+}
+
+// InsertBombEvent inserts a single bomb event
+func InsertBombEvent(bombEvent *shared.BombEvent) error {
+	return InsertBombEvents([]shared.BombEvent{*bombEvent})
+}
+
+// InsertUtilityEvents inserts multiple utility events using batch operation
+func InsertUtilityEvents(utilityEvents []shared.UtilityEvent) error {
+	if ClickHouseClient == nil {
+		return ErrNoClient
+	}
+
+	if len(utilityEvents) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+
+	// Prepare batch insert
+	batch, err := ClickHouseClient.PrepareBatch(ctx, fmt.Sprintf(`
+        INSERT INTO %s (
+            match_id, round, map, team, steamid, name, mode, event_id,
+            grenade, action, count, value,
+            timestamp
+        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `, utilityEventTableName))
+
+	if err != nil {
+		return fmt.Errorf("unable to prepare batch statement: %v", err)
+	}
+
+	// Append all utility events to batch
+	for _, event := range utilityEvents {
+		err = batch.Append(
+			event.MatchID,
+			event.Round,
+			event.Map,
+			event.Team,
+			event.SteamID,
+			event.Name,
+			event.Mode,
+			event.EventID,
+			event.Grenade,
+			event.Action,
+			event.Count,
+			event.Value,
+			event.Timestamp,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to append utility event to batch: %v", err)
+		}
+	}
+
+	// Execute batch
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("failed to execute batch insert for utility events: %v", err)
+	}
+
+	return nil
+}
+
+// InsertUtilityEvent inserts a single utility event
+func InsertUtilityEvent(utilityEvent *shared.UtilityEvent) error {
+	return InsertUtilityEvents([]shared.UtilityEvent{*utilityEvent})
+}
+
+// InsertDamageEvents inserts multiple damage events using batch operation
+func InsertDamageEvents(damageEvents []shared.DamageEvent) error {
+	if ClickHouseClient == nil {
+		return ErrNoClient
+	}
+
+	if len(damageEvents) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+
+	// Prepare batch insert
+	batch, err := ClickHouseClient.PrepareBatch(ctx, fmt.Sprintf(`
+        INSERT INTO %s (
+            match_id, round, map, team, steamid, name, mode, event_id,
+            health_damage, armor_damage, health, armor, fatal,
+            fight_start, fight_health, fight_hits,
+            round_phase, round_clock, timestamp
+        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `, damageEventTableName))
+
+	if err != nil {
+		return fmt.Errorf("unable to prepare batch statement: %v", err)
+	}
+
+	// Append all damage events to batch
+	for _, event := range damageEvents {
+		err = batch.Append(
+			event.MatchID,
+			event.Round,
+			event.Map,
+			event.Team,
+			event.SteamID,
+			event.Name,
+			event.Mode,
+			event.EventID,
+			event.HealthDamage,
+			event.ArmorDamage,
+			event.Health,
+			event.Armor,
+			event.Fatal,
+			event.FightStart,
+			event.FightHealth,
+			event.FightHits,
+			event.RoundPhase,
+			event.RoundClock,
+			event.Timestamp,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to append damage event to batch: %v", err)
+		}
+	}
+
+	// Execute batch
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("failed to execute batch insert for damage events: %v", err)
+	}
+
+	return nil
+}
+
+// InsertDamageEvent inserts a single damage event
+func InsertDamageEvent(damageEvent *shared.DamageEvent) error {
+	return InsertDamageEvents([]shared.DamageEvent{*damageEvent})
+}
+
+// InsertClutchEvents inserts multiple clutch events using batch operation
+func InsertClutchEvents(clutchEvents []shared.ClutchEvent) error {
+	if ClickHouseClient == nil {
+		return ErrNoClient
+	}
+
+	if len(clutchEvents) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+
+	// Prepare batch insert
+	batch, err := ClickHouseClient.PrepareBatch(ctx, fmt.Sprintf(`
+        INSERT INTO %s (
+            match_id, round, map, team, steamid, name, mode, event_id,
+            action, vs, enemies, kills,
+            timestamp
+        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `, clutchEventTableName))
+
+	if err != nil {
+		return fmt.Errorf("unable to prepare batch statement: %v", err)
+	}
+
+	// Append all clutch events to batch
+	for _, event := range clutchEvents {
+		err = batch.Append(
+			event.MatchID,
+			event.Round,
+			event.Map,
+			event.Team,
+			event.SteamID,
+			event.Name,
+			event.Mode,
+			event.EventID,
+			event.Action,
+			event.Vs,
+			event.Enemies,
+			event.Kills,
+			event.Timestamp,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to append clutch event to batch: %v", err)
+		}
+	}
+
+	// Execute batch
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("failed to execute batch insert for clutch events: %v", err)
+	}
+
+	return nil
+}
+
+// InsertClutchEvent inserts a single clutch event
+func InsertClutchEvent(clutchEvent *shared.ClutchEvent) error {
+	return InsertClutchEvents([]shared.ClutchEvent{*clutchEvent})
+}
+
+// InsertMultiKillEvents inserts multiple multi-kill events using batch operation
+func InsertMultiKillEvents(multiKillEvents []shared.MultiKillEvent) error {
+	if ClickHouseClient == nil {
+		return ErrNoClient
+	}
+
+	if len(multiKillEvents) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+
+	// Prepare batch insert
+	batch, err := ClickHouseClient.PrepareBatch(ctx, fmt.Sprintf(`
+        INSERT INTO %s (
+            match_id, round, map, team, steamid, name, mode, event_id,
+            kills, headshots, label,
+            timestamp
+        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `, multiKillEventTableName))
+
+	if err != nil {
+		return fmt.Errorf("unable to prepare batch statement: %v", err)
+	}
+
+	// Append all multi-kill events to batch
+	for _, event := range multiKillEvents {
+		err = batch.Append(
+			event.MatchID,
+			event.Round,
+			event.Map,
+			event.Team,
+			event.SteamID,
+			event.Name,
+			event.Mode,
+			event.EventID,
+			event.Kills,
+			event.Headshots,
+			event.Label,
+			event.Timestamp,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to append multi-kill event to batch: %v", err)
+		}
+	}
+
+	// Execute batch
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("failed to execute batch insert for multi-kill events: %v", err)
+	}
+
+	return nil
+}
+
+// InsertMultiKillEvent inserts a single multi-kill event
+func InsertMultiKillEvent(multiKillEvent *shared.MultiKillEvent) error {
+	return InsertMultiKillEvents([]shared.MultiKillEvent{*multiKillEvent})
+}
+
+// InsertBadgeEvents inserts multiple badge events using batch operation
+func InsertBadgeEvents(badgeEvents []shared.BadgeEvent) error {
+	if ClickHouseClient == nil {
+		return ErrNoClient
+	}
+
+	if len(badgeEvents) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+
+	// Prepare batch insert
+	batch, err := ClickHouseClient.PrepareBatch(ctx, fmt.Sprintf(`
+        INSERT INTO %s (
+            match_id, round, map, team, steamid, name, mode, event_id,
+            badge, count,
+            timestamp
+        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `, badgeEventTableName))
+
+	if err != nil {
+		return fmt.Errorf("unable to prepare batch statement: %v", err)
+	}
+
+	// Append all badge events to batch
+	for _, event := range badgeEvents {
+		err = batch.Append(
+			event.MatchID,
+			event.Round,
+			event.Map,
+			event.Team,
+			event.SteamID,
+			event.Name,
+			event.Mode,
+			event.EventID,
+			event.Badge,
+			event.Count,
+			event.Timestamp,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to append badge event to batch: %v", err)
+		}
+	}
+
+	// Execute batch
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("failed to execute batch insert for badge events: %v", err)
+	}
+
+	return nil
+}
+
+// InsertBadgeEvent inserts a single badge event
+func InsertBadgeEvent(badgeEvent *shared.BadgeEvent) error {
+	return InsertBadgeEvents([]shared.BadgeEvent{*badgeEvent})
+}
+
+// InsertDuelEvents inserts multiple duel events using batch operation
+func InsertDuelEvents(duelEvents []shared.DuelEvent) error {
+	if ClickHouseClient == nil {
+		return ErrNoClient
+	}
+
+	if len(duelEvents) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+
+	// Prepare batch insert
+	batch, err := ClickHouseClient.PrepareBatch(ctx, fmt.Sprintf(`
+        INSERT INTO %s (
+            match_id, round, map, team, steamid, name, mode, event_id,
+            kind, opening, trade, tracked, trade_window,
+            timestamp
+        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `, duelEventTableName))
+
+	if err != nil {
+		return fmt.Errorf("unable to prepare batch statement: %v", err)
+	}
+
+	// Append all duel events to batch
+	for _, event := range duelEvents {
+		err = batch.Append(
+			event.MatchID,
+			event.Round,
+			event.Map,
+			event.Team,
+			event.SteamID,
+			event.Name,
+			event.Mode,
+			event.EventID,
+			event.Kind,
+			event.Opening,
+			event.Trade,
+			event.Tracked,
+			event.Window,
+			event.Timestamp,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to append duel event to batch: %v", err)
+		}
+	}
+
+	// Execute batch
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("failed to execute batch insert for duel events: %v", err)
+	}
+
+	return nil
+}
+
+// InsertDuelEvent inserts a single duel event
+func InsertDuelEvent(duelEvent *shared.DuelEvent) error {
+	return InsertDuelEvents([]shared.DuelEvent{*duelEvent})
+}
+
+// InsertDeadLetters inserts multiple dead letters using batch operation
+func InsertDeadLetters(deadLetters []shared.DeadLetter) error {
+	if ClickHouseClient == nil {
+		return ErrNoClient
+	}
+
+	if len(deadLetters) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+
+	// Prepare batch insert
+	batch, err := ClickHouseClient.PrepareBatch(ctx, fmt.Sprintf(`
+        INSERT INTO %s (
+            id, topic, consumer_group, message_key, message_value, message_offset,
+            error, attempts, failed_at,
+            redriven, updated_at
+        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `, deadLetterTableName))
+
+	if err != nil {
+		return fmt.Errorf("unable to prepare batch statement: %v", err)
+	}
+
+	// Append all dead letters to batch
+	for _, dl := range deadLetters {
+		err = batch.Append(
+			dl.ID,
+			dl.Topic,
+			dl.Group,
+			dl.Key,
+			dl.Value,
+			dl.Offset,
+			dl.Error,
+			dl.Attempts,
+			dl.FailedAt,
+			false,
+			dl.FailedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to append dead letter to batch: %v", err)
+		}
+	}
+
+	// Execute batch
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("failed to execute batch insert for dead letters: %v", err)
+	}
+
+	return nil
+}
+
+// InsertDeadLetter inserts a single dead letter
+func InsertDeadLetter(deadLetter *shared.DeadLetter) error {
+	return InsertDeadLetters([]shared.DeadLetter{*deadLetter})
+}
+
+// CreateTables creates the necessary ClickHouse tables
+func CreateTables() error {
+	if ClickHouseClient == nil {
+		return ErrNoClient
+	}
+
+	ctx := context.Background()
+
+	// Create kill events table
+	killEventSchema := fmt.Sprintf(`
+        CREATE TABLE IF NOT EXISTS %s (
+            match_id String,
+            round UInt32,
+            map String,
+            team String,
+            steamid String,
+            name String,
+            mode String,
+            event_id String,
+            weapon_name String,
+            weapon_type String,
+            weapon_ammo UInt32,
+            weapon_reserve UInt32,
+            weapon_skin String,
+            weapon_headshot Bool,
+            headshot_exact Bool DEFAULT true,
+            timestamp Int64
+        ) ENGINE = ReplacingMergeTree()
+        ORDER BY (%s)
+        PARTITION BY toDate(fromUnixTimestamp(timestamp))
+    `, killEventTableName, eventSortingKey)
+
+	if err := ClickHouseClient.Exec(ctx, killEventSchema); err != nil {
+		return fmt.Errorf("failed to create kill events table: %v", err)
+	}
+
+	// Tables created before per-kill headshot attribution lack the column
+	if err := ClickHouseClient.Exec(ctx, fmt.Sprintf(
+		"ALTER TABLE %s ADD COLUMN IF NOT EXISTS headshot_exact Bool DEFAULT true AFTER weapon_headshot",
+		killEventTableName,
+	)); err != nil {
+		return fmt.Errorf("failed to migrate kill events table: %v", err)
+	}
+
+	// Create player events table
+	playerEventSchema := fmt.Sprintf(`
+        CREATE TABLE IF NOT EXISTS %s (
+            match_id String,
+            round UInt32,
+            map String,
+            team String,
+            steamid String,
+            name String,
+            mode String,
+            event_id String,
+            health UInt32,
+            armor UInt32,
+            helmet Bool,
+            money UInt32,
+            equip_value UInt32,
+            flashed UInt32,
+            smoked UInt32,
+            burning UInt32,
+            round_kills UInt32,
+            round_killhs UInt32,
+            kills UInt32,
+            assists UInt32,
+            deaths UInt32,
+            mvps UInt32,
+            score UInt32,
+            event_timestamp Int64,
+            win_team String
+        ) ENGINE = ReplacingMergeTree()
+        ORDER BY (%s)
+        PARTITION BY toDate(fromUnixTimestamp(event_timestamp))
+    `, playerEventTableName, eventSortingKey)
+
+	if err := ClickHouseClient.Exec(ctx, playerEventSchema); err != nil {
+		return fmt.Errorf("failed to create player events table: %v", err)
+	}
+
+	// Tables created before exposure tracking lack the effect columns
+	for _, column := range []string{"burning", "smoked", "flashed"} {
+		if err := ClickHouseClient.Exec(ctx, fmt.Sprintf(
+			"ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s UInt32 DEFAULT 0 AFTER equip_value",
+			playerEventTableName, column,
+		)); err != nil {
+			return fmt.Errorf("failed to migrate player events table: %v", err)
+		}
+	}
+
+	// Create death events table
+	deathEventSchema := fmt.Sprintf(`
+        CREATE TABLE IF NOT EXISTS %s (
+            match_id String,
+            round UInt32,
+            map String,
+            team String,
+            steamid String,
+            name String,
+            mode String,
+            event_id String,
+            round_phase String,
+            round_clock Float64,
+            weapon_name String,
+            weapon_type String,
+            equip_value UInt32,
+            bomb_planted Bool,
+            timestamp Int64
+        ) ENGINE = ReplacingMergeTree()
+        ORDER BY (%s)
+        PARTITION BY toDate(fromUnixTimestamp(timestamp))
+    `, deathEventTableName, eventSortingKey)
+
+	if err := ClickHouseClient.Exec(ctx, deathEventSchema); err != nil {
+		return fmt.Errorf("failed to create death events table: %v", err)
+	}
+
+	// Create assist events table
+	assistEventSchema := fmt.Sprintf(`
+        CREATE TABLE IF NOT EXISTS %s (
+            match_id String,
+            round UInt32,
+            map String,
+            team String,
+            steamid String,
+            name String,
+            mode String,
+            event_id String,
+            weapon_name String,
+            weapon_type String,
+            flash_assist Bool,
+            timestamp Int64
+        ) ENGINE = ReplacingMergeTree()
+        ORDER BY (%s)
+        PARTITION BY toDate(fromUnixTimestamp(timestamp))
+    `, assistEventTableName, eventSortingKey)
+
+	if err := ClickHouseClient.Exec(ctx, assistEventSchema); err != nil {
+		return fmt.Errorf("failed to create assist events table: %v", err)
+	}
+
+	// Create matches table, one row per match and client. ended_at is the
+	// version, so the match_ended row replaces the match_started one even if
+	// that is delivered again afterwards.
+	matchSchema := fmt.Sprintf(`
+        CREATE TABLE IF NOT EXISTS %s (
+            match_id String,
+            steamid String,
+            map String,
+            mode String,
+            team String,
+            state String,
+            joined_in_progress Bool,
+            score_ct UInt32,
+            score_t UInt32,
+            rounds UInt32,
+            winner String,
+            result String,
+            overtime Bool,
+            started_at Int64,
+            ended_at Int64,
+            duration Int64
+        ) ENGINE = ReplacingMergeTree(ended_at)
+        ORDER BY (steamid, match_id)
+    `, matchTableName)
+
+	if err := ClickHouseClient.Exec(ctx, matchSchema); err != nil {
+		return fmt.Errorf("failed to create matches table: %v", err)
+	}
+
+	// Tables created without a version keep whichever row came last
+	if err := migrateMatchTable(ctx, matchSchema); err != nil {
+		return fmt.Errorf("failed to migrate matches table: %v", err)
+	}
+
+	// Create round summaries table
+	roundSummarySchema := fmt.Sprintf(`
+        CREATE TABLE IF NOT EXISTS %s (
+            match_id String,
+            round UInt32,
+            map String,
+            team String,
+            steamid String,
+            name String,
+            mode String,
+            event_id String,
+            start_money UInt32,
+            spent UInt32,
+            equip_value UInt32,
+            buy_type String,
+            losses UInt32,
+            loss_bonus UInt32,
+            min_next_money UInt32,
+            can_full_buy_next Bool,
+            bad_buy String,
+            kills UInt32,
+            headshots UInt32,
+            health_lost UInt32,
+            survived Bool,
+            utility_bought UInt32,
+            utility_thrown UInt32,
+            utility_lost UInt32,
+            utility_spent UInt32,
+            flashed_seconds Float64,
+            peak_flashed UInt32,
+            smoked_seconds Float64,
+            peak_smoked UInt32,
+            burning_seconds Float64,
+            peak_burning UInt32,
+            died_flashed Bool,
+            timeline_at Array(Int64),
+            timeline_health Array(UInt32),
+            timeline_armor Array(UInt32),
+            win_team String,
+            win_condition String,
+            bomb_outcome String,
+            partial Bool,
+            timestamp Int64
+        ) ENGINE = ReplacingMergeTree()
+        ORDER BY (%s)
+        PARTITION BY toDate(fromUnixTimestamp(timestamp))
+    `, roundSummaryTableName, eventSortingKey)
+
+	if err := ClickHouseClient.Exec(ctx, roundSummarySchema); err != nil {
+		return fmt.Errorf("failed to create round summaries table: %v", err)
+	}
+
+	// Tables created before utility tracking lack the utility columns
+	for _, column := range []string{"utility_spent", "utility_lost", "utility_thrown", "utility_bought"} {
+		if err := ClickHouseClient.Exec(ctx, fmt.Sprintf(
+			"ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s UInt32 DEFAULT 0 AFTER survived",
+			roundSummaryTableName, column,
+		)); err != nil {
+			return fmt.Errorf("failed to migrate round summaries table: %v", err)
+		}
+	}
+
+	// Tables created before exposure tracking lack the exposure columns
+	for _, column := range []string{
+		"died_flashed Bool DEFAULT false",
+		"peak_burning UInt32 DEFAULT 0",
+		"burning_seconds Float64 DEFAULT 0",
+		"peak_smoked UInt32 DEFAULT 0",
+		"smoked_seconds Float64 DEFAULT 0",
+		"peak_flashed UInt32 DEFAULT 0",
+		"flashed_seconds Float64 DEFAULT 0",
+	} {
+		if err := ClickHouseClient.Exec(ctx, fmt.Sprintf(
+			"ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s AFTER utility_spent",
+			roundSummaryTableName, column,
+		)); err != nil {
+			return fmt.Errorf("failed to migrate round summaries table: %v", err)
+		}
+	}
+
+	// Tables created before buy classification lack the buy type
+	if err := ClickHouseClient.Exec(ctx, fmt.Sprintf(
+		"ALTER TABLE %s ADD COLUMN IF NOT EXISTS buy_type String DEFAULT '' AFTER equip_value",
+		roundSummaryTableName,
+	)); err != nil {
+		return fmt.Errorf("failed to migrate round summaries table: %v", err)
+	}
+
+	// Tables created before the economy forecast lack its columns
+	for _, column := range []string{
+		"bad_buy String DEFAULT ''",
+		"can_full_buy_next Bool DEFAULT false",
+		"min_next_money UInt32 DEFAULT 0",
+		"loss_bonus UInt32 DEFAULT 0",
+		"losses UInt32 DEFAULT 0",
+	} {
+		if err := ClickHouseClient.Exec(ctx, fmt.Sprintf(
+			"ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s AFTER buy_type",
+			roundSummaryTableName, column,
+		)); err != nil {
+			return fmt.Errorf("failed to migrate round summaries table: %v", err)
+		}
+	}
+
+	// Tables created before the health timeline lack its columns
+	for _, column := range []string{
+		"timeline_armor Array(UInt32)",
+		"timeline_health Array(UInt32)",
+		"timeline_at Array(Int64)",
+	} {
+		if err := ClickHouseClient.Exec(ctx, fmt.Sprintf(
+			"ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s AFTER died_flashed",
+			roundSummaryTableName, column,
+		)); err != nil {
+			return fmt.Errorf("failed to migrate round summaries table: %v", err)
+		}
+	}
+
+	// Create utility events table
+	utilityEventSchema := fmt.Sprintf(`
+        CREATE TABLE IF NOT EXISTS %s (
+            match_id String,
+            round UInt32,
+            map String,
+            team String,
+            steamid String,
+            name String,
+            mode String,
+            event_id String,
+            grenade String,
+            action String,
+            count UInt32,
+            value UInt32,
+            timestamp Int64
+        ) ENGINE = ReplacingMergeTree()
+        ORDER BY (%s)
+        PARTITION BY toDate(fromUnixTimestamp(timestamp))
+    `, utilityEventTableName, eventSortingKey)
+
+	if err := ClickHouseClient.Exec(ctx, utilityEventSchema); err != nil {
+		return fmt.Errorf("failed to create utility events table: %v", err)
+	}
+
+	// Create bomb events table
+	bombEventSchema := fmt.Sprintf(`
+        CREATE TABLE IF NOT EXISTS %s (
+            match_id String,
+            round UInt32,
+            map String,
+            team String,
+            steamid String,
+            name String,
+            mode String,
+            event_id String,
+            action String,
+            actor String,
+            by_player Bool,
+            site String,
+            position String,
+            timestamp Int64
+        ) ENGINE = ReplacingMergeTree()
+        ORDER BY (%s)
+        PARTITION BY toDate(fromUnixTimestamp(timestamp))
+    `, bombEventTableName, eventSortingKey)
+
+	if err := ClickHouseClient.Exec(ctx, bombEventSchema); err != nil {
+		return fmt.Errorf("failed to create bomb events table: %v", err)
+	}
+
+	// Create damage events table
+	damageEventSchema := fmt.Sprintf(`
+        CREATE TABLE IF NOT EXISTS %s (
+            match_id String,
+            round UInt32,
+            map String,
+            team String,
+            steamid String,
+            name String,
+            mode String,
+            event_id String,
+            health_damage UInt32,
+            armor_damage UInt32,
+            health UInt32,
+            armor UInt32,
+            fatal Bool,
+            fight_start Bool,
+            fight_health UInt32,
+            fight_hits UInt32,
+            round_phase String,
+            round_clock Float64,
+            timestamp Int64
+        ) ENGINE = ReplacingMergeTree()
+        ORDER BY (%s)
+        PARTITION BY toDate(fromUnixTimestamp(timestamp))
+    `, damageEventTableName, eventSortingKey)
+
+	if err := ClickHouseClient.Exec(ctx, damageEventSchema); err != nil {
+		return fmt.Errorf("failed to create damage events table: %v", err)
+	}
+
+	// Create clutch events table
+	clutchEventSchema := fmt.Sprintf(`
+        CREATE TABLE IF NOT EXISTS %s (
+            match_id String,
+            round UInt32,
+            map String,
+            team String,
+            steamid String,
+            name String,
+            mode String,
+            event_id String,
+            action String,
+            vs UInt32,
+            enemies Int32,
+            kills UInt32,
+            timestamp Int64
+        ) ENGINE = ReplacingMergeTree()
+        ORDER BY (%s)
+        PARTITION BY toDate(fromUnixTimestamp(timestamp))
+    `, clutchEventTableName, eventSortingKey)
+
+	if err := ClickHouseClient.Exec(ctx, clutchEventSchema); err != nil {
+		return fmt.Errorf("failed to create clutch events table: %v", err)
+	}
+
+	// Create multi-kill events table
+	multiKillEventSchema := fmt.Sprintf(`
+        CREATE TABLE IF NOT EXISTS %s (
+            match_id String,
+            round UInt32,
+            map String,
+            team String,
+            steamid String,
+            name String,
+            mode String,
+            event_id String,
+            kills UInt32,
+            headshots UInt32,
+            label String,
+            timestamp Int64
+        ) ENGINE = ReplacingMergeTree()
+        ORDER BY (%s)
+        PARTITION BY toDate(fromUnixTimestamp(timestamp))
+    `, multiKillEventTableName, eventSortingKey)
+
+	if err := ClickHouseClient.Exec(ctx, multiKillEventSchema); err != nil {
+		return fmt.Errorf("failed to create multi-kill events table: %v", err)
+	}
+
+	// Create badge events table
+	badgeEventSchema := fmt.Sprintf(`
+        CREATE TABLE IF NOT EXISTS %s (
+            match_id String,
+            round UInt32,
+            map String,
+            team String,
+            steamid String,
+            name String,
+            mode String,
+            event_id String,
+            badge String,
+            count UInt32,
+            timestamp Int64
+        ) ENGINE = ReplacingMergeTree()
+        ORDER BY (%s)
+        PARTITION BY toDate(fromUnixTimestamp(timestamp))
+    `, badgeEventTableName, eventSortingKey)
+
+	if err := ClickHouseClient.Exec(ctx, badgeEventSchema); err != nil {
+		return fmt.Errorf("failed to create badge events table: %v", err)
+	}
+
+	// Create duel events table
+	duelEventSchema := fmt.Sprintf(`
+        CREATE TABLE IF NOT EXISTS %s (
+            match_id String,
+            round UInt32,
+            map String,
+            team String,
+            steamid String,
+            name String,
+            mode String,
+            event_id String,
+            kind String,
+            opening Bool,
+            trade Bool,
+            tracked Bool,
+            trade_window Int64,
+            timestamp Int64
+        ) ENGINE = ReplacingMergeTree()
+        ORDER BY (%s)
+        PARTITION BY toDate(fromUnixTimestamp(timestamp))
+    `, duelEventTableName, eventSortingKey)
+
+	if err := ClickHouseClient.Exec(ctx, duelEventSchema); err != nil {
+		return fmt.Errorf("failed to create duel events table: %v", err)
+	}
+
+	// Create dead letters table. Re-driving a dead letter inserts a newer
+	// version of its row
+	deadLetterSchema := fmt.Sprintf(`
+        CREATE TABLE IF NOT EXISTS %s (
+            id String,
+            topic String,
+            consumer_group String,
+            message_key String,
+            message_value String,
+            message_offset Int64,
+            error String,
+            attempts UInt32,
+            failed_at Int64,
+            redriven Bool,
+            updated_at Int64
+        ) ENGINE = ReplacingMergeTree(updated_at)
+        ORDER BY id
+    `, deadLetterTableName)
+
+	if err := ClickHouseClient.Exec(ctx, deadLetterSchema); err != nil {
+		return fmt.Errorf("failed to create dead letters table: %v", err)
+	}
+
+	// Tables created before event IDs, or sorted by when events were seen,
+	// keep more than one copy of an event
+	for _, table := range []struct{ name, schema string }{
+		{killEventTableName, killEventSchema},
+		{playerEventTableName, playerEventSchema},
+		{deathEventTableName, deathEventSchema},
+		{assistEventTableName, assistEventSchema},
+		{roundSummaryTableName, roundSummarySchema},
+		{utilityEventTableName, utilityEventSchema},
+		{bombEventTableName, bombEventSchema},
+		{damageEventTableName, damageEventSchema},
+		{clutchEventTableName, clutchEventSchema},
+		{multiKillEventTableName, multiKillEventSchema},
+		{badgeEventTableName, badgeEventSchema},
+		{duelEventTableName, duelEventSchema},
+	} {
+		if err := migrateEventTable(ctx, table.name, table.schema); err != nil {
+			return fmt.Errorf("failed to migrate %s to event IDs: %v", table.name, err)
+		}
+	}
+
+	return nil
+}
+
+// migrateEventTable rebuilds table as created by schema if it is still a
+// plain MergeTree or sorted by anything but eventSortingKey: the event_id
+// column is added and the rows are copied into a ReplacingMergeTree, which
+// keeps one row per event. Rows stored before event IDs each get a random
+// one, as their copies can't be told apart.
+func migrateEventTable(ctx context.Context, table, schema string) error {
+	var engine, sortingKey string
+	if err := ClickHouseClient.QueryRow(ctx,
+		"SELECT engine, sorting_key FROM system.tables WHERE database = currentDatabase() AND name = ?", table,
+	).Scan(&engine, &sortingKey); err != nil {
+		return err
+	}
+	if engine == "ReplacingMergeTree" && sortingKey == eventSortingKey {
+		return nil
+	}
+
+	if err := ClickHouseClient.Exec(ctx, fmt.Sprintf(
+		"ALTER TABLE %s ADD COLUMN IF NOT EXISTS event_id String DEFAULT '' AFTER mode", table,
+	)); err != nil {
+		return err
+	}
+
+	if err := rebuildTable(ctx, table, schema); err != nil {
+		return err
+	}
+
+	log.Printf("Rebuilt %s to keep one row per event", table)
+	return nil
+}
+
+// migrateMatchTable rebuilds the matches table as created by schema if it
+// isn't versioned by ended_at.
+func migrateMatchTable(ctx context.Context, schema string) error {
+	var engine string
+	if err := ClickHouseClient.QueryRow(ctx,
+		"SELECT engine_full FROM system.tables WHERE database = currentDatabase() AND name = ?", matchTableName,
+	).Scan(&engine); err != nil {
+		return err
+	}
+	if strings.HasPrefix(engine, "ReplacingMergeTree(ended_at)") {
+		return nil
+	}
+
+	if err := rebuildTable(ctx, matchTableName, schema); err != nil {
+		return err
+	}
+
+	log.Printf("Rebuilt %s to keep the ended row of each match", matchTableName)
+	return nil
+}
+
+// rebuildTable copies every row of table into a new one created by schema
+// and swaps it in. Rows without an event ID each get a random one.
+func rebuildTable(ctx context.Context, table, schema string) error {
+	rows, err := ClickHouseClient.Query(ctx,
+		"SELECT name FROM system.columns WHERE database = currentDatabase() AND table = ? ORDER BY position", table,
+	)
+	if err != nil {
+		return err
+	}
+	var columns, values []string
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			rows.Close()
+			return err
+		}
+		value := column
+		if column == "event_id" {
+			value = "if(event_id = '', toString(generateUUIDv4()), event_id)"
+		}
+		columns = append(columns, column)
+		values = append(values, value)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// A leftover from a rebuild that didn't finish is incomplete
+	rebuilt := table + "_rebuilt"
+	if err := ClickHouseClient.Exec(ctx, "DROP TABLE IF EXISTS "+rebuilt); err != nil {
+		return err
+	}
+	if err := ClickHouseClient.Exec(ctx, strings.Replace(schema, "EXISTS "+table, "EXISTS "+rebuilt, 1)); err != nil {
+		return err
+	}
+
+	// Copying can take longer than queries are normally allowed
+	copyCtx := clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{"max_execution_time": 0}))
+	if err := ClickHouseClient.Exec(copyCtx, fmt.Sprintf(
+		"INSERT INTO %s (%s) SELECT %s FROM %s",
+		rebuilt, strings.Join(columns, ", "), strings.Join(values, ", "), table,
+	)); err != nil {
+		return err
+	}
+
+	if err := ClickHouseClient.Exec(ctx, fmt.Sprintf("EXCHANGE TABLES %s AND %s", table, rebuilt)); err != nil {
+		return err
+	}
+	return ClickHouseClient.Exec(ctx, "DROP TABLE "+rebuilt)
 }
@@ -0,0 +1,60 @@
+package economy
+
+import "github.com/ukpabik/CSYou/pkg/match_events"
+
+const (
+	BUY_PISTOL = "pistol"
+	BUY_ECO    = "eco"
+	BUY_FORCE  = "force"
+	BUY_HALF   = "half"
+	BUY_FULL   = "full"
+)
+
+// BuyThresholds are the equipment values, at the end of freezetime, that
+// separate the buy types for one side.
+type BuyThresholds struct {
+	Eco  int // below this is an eco
+	Full int // at or above this is a full buy
+}
+
+// BUY_THRESHOLDS are per side, since the CT rifle and defuse kit cost more.
+var BUY_THRESHOLDS = map[string]BuyThresholds{
+	"T":  {Eco: 1500, Full: 3700},
+	"CT": {Eco: 1500, Full: 4100},
+}
+
+// HALF_BUY_SAVED is how much money a player must keep back for a partial buy
+// to be a half buy rather than a force.
+const HALF_BUY_SAVED = 1000
+
+// ClassifyBuy labels a player's buy from their money and equipment value at
+// the end of freezetime. round counts completed rounds, as GSI does.
+func ClassifyBuy(mode, side string, round, money, equipValue int) string {
+	if IsPistolRound(mode, round) {
+		return BUY_PISTOL
+	}
+
+	thresholds, ok := BUY_THRESHOLDS[side]
+	if !ok {
+		return ""
+	}
+
+	switch {
+	case equipValue < thresholds.Eco:
+		return BUY_ECO
+	case equipValue >= thresholds.Full:
+		return BUY_FULL
+	case money >= HALF_BUY_SAVED:
+		return BUY_HALF
+	}
+	return BUY_FORCE
+}
+
+// IsPistolRound reports whether round is the first of a half in regulation.
+func IsPistolRound(mode string, round int) bool {
+	if round == 0 {
+		return true
+	}
+	regulation := match_events.RegulationRounds(mode)
+	return regulation > 0 && round == regulation/2
+}
@@ -0,0 +1,33 @@
+package economy
+
+import "testing"
+
+func TestClassifyBuy(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		mode       string
+		side       string
+		round      int
+		money      int
+		equipValue int
+		want       string
+	}{
+		{"first pistol round", "competitive", "T", 0, 0, 1000, BUY_PISTOL},
+		{"second half pistol round", "competitive", "CT", 12, 0, 1000, BUY_PISTOL},
+		{"round after the pistol", "competitive", "CT", 1, 2000, 1000, BUY_ECO},
+		{"wingman second half pistol round", "wingman", "T", 8, 0, 900, BUY_PISTOL},
+		{"wingman round 12 isn't a pistol round", "wingman", "T", 12, 0, 900, BUY_ECO},
+		{"casual has one pistol round", "casual", "T", 12, 0, 900, BUY_ECO},
+		{"eco", "competitive", "T", 3, 3000, 1499, BUY_ECO},
+		{"force", "competitive", "T", 3, 999, 1500, BUY_FORCE},
+		{"half buy", "competitive", "T", 3, 1000, 2500, BUY_HALF},
+		{"T full buy", "competitive", "T", 3, 0, 3700, BUY_FULL},
+		{"CT short of a full buy at the T threshold", "competitive", "CT", 3, 0, 3700, BUY_FORCE},
+		{"CT full buy", "competitive", "CT", 3, 0, 4100, BUY_FULL},
+		{"spectator", "competitive", "", 3, 0, 4100, ""},
+	} {
+		if got := ClassifyBuy(tc.mode, tc.side, tc.round, tc.money, tc.equipValue); got != tc.want {
+			t.Errorf("%s: ClassifyBuy = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
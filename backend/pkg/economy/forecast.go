@@ -0,0 +1,89 @@
+package economy
+
+import "github.com/ukpabik/CSYou/pkg/match_events"
+
+const (
+	LOSS_BONUS_BASE   = 1400  // for the first loss in a row
+	LOSS_BONUS_STEP   = 500   // added for each further loss
+	LOSS_BONUS_LEVELS = 4     // steps before the bonus stops growing
+	PISTOL_MONEY      = 800   // everyone's money at the start of a half
+	OVERTIME_MONEY    = 12500 // everyone's money at the start of an overtime half
+	OVERTIME_HALF     = 3     // rounds per overtime half
+	MAX_MONEY         = 16000
+)
+
+const (
+	// BAD_BUY_AFTER_PISTOL_LOSS is buying after losing the pistol round when
+	// the team should be saving for the round after.
+	BAD_BUY_AFTER_PISTOL_LOSS = "after_pistol_loss"
+	// BAD_BUY_BROKE_ECONOMY is a force or half buy that costs the player the
+	// full buy they would have had next round by saving.
+	BAD_BUY_BROKE_ECONOMY = "broke_economy"
+)
+
+// Forecast is what a player's buy this round means for the next one.
+type Forecast struct {
+	BuyType      string
+	LossBonus    int    // income for losing this round
+	MinNextMoney int    // money next round if this one is lost, kill rewards aside
+	CanFullBuy   bool   // whether MinNextMoney affords a full buy
+	BadBuy       string // BAD_BUY_* reason, or ""
+}
+
+// LossBonus returns the income for losing a round after the team has lost
+// losses rounds in a row.
+func LossBonus(losses int) int {
+	return LOSS_BONUS_BASE + LOSS_BONUS_STEP*min(max(losses, 0), LOSS_BONUS_LEVELS)
+}
+
+// HalfStartMoney returns the money everyone is reset to if round starts a
+// half, regulation or overtime.
+func HalfStartMoney(mode string, round int) (int, bool) {
+	if IsPistolRound(mode, round) {
+		return PISTOL_MONEY, true
+	}
+	regulation := match_events.RegulationRounds(mode)
+	if regulation > 0 && round >= regulation && (round-regulation)%OVERTIME_HALF == 0 {
+		return OVERTIME_MONEY, true
+	}
+	return 0, false
+}
+
+// ForecastRound models a player's economy from their team's losing streak
+// and their money at the start and end of freezetime. round counts completed
+// rounds, as GSI does.
+func ForecastRound(mode, side string, round, losses, startMoney, money, equipValue int) Forecast {
+	f := Forecast{
+		BuyType:   ClassifyBuy(mode, side, round, money, equipValue),
+		LossBonus: LossBonus(losses),
+	}
+
+	// Money is reset for the next round, so nothing bought now carries over
+	if reset, ok := HalfStartMoney(mode, round+1); ok {
+		f.MinNextMoney = reset
+		f.CanFullBuy = canFullBuy(side, reset)
+		return f
+	}
+
+	f.MinNextMoney = min(money+f.LossBonus, MAX_MONEY)
+	f.CanFullBuy = canFullBuy(side, f.MinNextMoney)
+	if f.CanFullBuy {
+		return f
+	}
+
+	bought := f.BuyType == BUY_FORCE || f.BuyType == BUY_HALF || f.BuyType == BUY_FULL
+	switch {
+	case !bought:
+	case round > 0 && IsPistolRound(mode, round-1) && losses > 0:
+		f.BadBuy = BAD_BUY_AFTER_PISTOL_LOSS
+	case f.BuyType != BUY_FULL && canFullBuy(side, min(startMoney+f.LossBonus, MAX_MONEY)):
+		f.BadBuy = BAD_BUY_BROKE_ECONOMY
+	}
+	return f
+}
+
+// canFullBuy reports whether money is enough for a full buy on side.
+func canFullBuy(side string, money int) bool {
+	thresholds, ok := BUY_THRESHOLDS[side]
+	return ok && money >= thresholds.Full
+}
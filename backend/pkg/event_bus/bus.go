@@ -0,0 +1,57 @@
+package event_bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ukpabik/CSYou/pkg/config"
+)
+
+const (
+	BACKEND_KAFKA  = "kafka"
+	BACKEND_MEMORY = "memory"
+)
+
+// ErrClosed is returned by Publish once the bus is closed.
+var ErrClosed = errors.New("event bus is closed")
+
+// Message is an event on a topic.
+type Message struct {
+	Topic  string
+	Key    []byte
+	Value  []byte
+	Offset int64 // position in the topic
+}
+
+// EventBus carries events from the GSI handlers to the consumers that store
+// them.
+type EventBus interface {
+	// Publish sends value to every group subscribed to topic.
+	Publish(ctx context.Context, topic string, key, value []byte) error
+
+	// Subscribe passes topic's messages to handle one at a time, blocking
+	// until the bus is closed or fails. Each group sees every message at
+	// least once; consumers in the same group share them. A message is only
+	// committed once handle returns nil. If it returns an error, Subscribe
+	// returns it and subscribing again starts from the first uncommitted
+	// message.
+	Subscribe(topic, group string, handle func(Message) error) error
+
+	// Close stops the bus. Consumers get the messages already published
+	// before their Subscribe returns.
+	Close() error
+}
+
+// New returns the bus chosen by cfg. kafkaAddr is only used by the Kafka
+// backend.
+func New(cfg config.EventBusConfig, kafkaAddr string) (EventBus, error) {
+	switch cfg.Backend {
+	case "", BACKEND_KAFKA:
+		return NewKafkaBus(kafkaAddr), nil
+	case BACKEND_MEMORY:
+		return NewMemoryBus(cfg.WALDir)
+	default:
+		return nil, fmt.Errorf("unknown event bus backend %q", cfg.Backend)
+	}
+}
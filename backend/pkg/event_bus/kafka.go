@@ -0,0 +1,137 @@
+package event_bus
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaBus is an EventBus on a Kafka broker. Each topic gets a writer on
+// first publish, and each subscription its own consumer group reader.
+type KafkaBus struct {
+	location string
+
+	mu      sync.Mutex
+	writers map[string]*kafka.Writer
+	readers []*kafka.Reader
+	closed  bool
+}
+
+// NewKafkaBus returns a bus on the broker at location, as host:port.
+func NewKafkaBus(location string) *KafkaBus {
+	return &KafkaBus{
+		location: location,
+		writers:  make(map[string]*kafka.Writer),
+	}
+}
+
+func (b *KafkaBus) Publish(ctx context.Context, topic string, key, value []byte) error {
+	writer, err := b.writer(topic)
+	if err != nil {
+		return err
+	}
+
+	return writer.WriteMessages(ctx, kafka.Message{Key: key, Value: value})
+}
+
+// writer returns the writer for topic, creating it on first use.
+func (b *KafkaBus) writer(topic string) (*kafka.Writer, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, ErrClosed
+	}
+	if writer, ok := b.writers[topic]; ok {
+		return writer, nil
+	}
+
+	writer := &kafka.Writer{
+		Addr:     kafka.TCP(b.location),
+		Topic:    topic,
+		Balancer: &kafka.LeastBytes{},
+	}
+	b.writers[topic] = writer
+	return writer, nil
+}
+
+func (b *KafkaBus) Subscribe(topic, group string, handle func(Message) error) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrClosed
+	}
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers: []string{b.location},
+		Topic:   topic,
+		GroupID: group,
+	})
+	b.readers = append(b.readers, reader)
+	b.mu.Unlock()
+
+	for {
+		message, err := reader.FetchMessage(context.Background())
+		if err != nil {
+			// Closing the reader is how the loop is stopped
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			b.dropReader(reader)
+			return err
+		}
+
+		err = handle(Message{
+			Topic:  message.Topic,
+			Key:    message.Key,
+			Value:  message.Value,
+			Offset: message.Offset,
+		})
+		if err == nil {
+			err = reader.CommitMessages(context.Background(), message)
+		}
+		if err != nil {
+			// A new reader starts again from the last commit
+			b.dropReader(reader)
+			return err
+		}
+	}
+}
+
+// dropReader closes a reader that failed, so it can be replaced by
+// subscribing again.
+func (b *KafkaBus) dropReader(reader *kafka.Reader) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i, r := range b.readers {
+		if r == reader {
+			b.readers = append(b.readers[:i], b.readers[i+1:]...)
+			break
+		}
+	}
+	if err := reader.Close(); err != nil {
+		log.Printf("failed to close reader: %v", err)
+	}
+}
+
+func (b *KafkaBus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for _, writer := range b.writers {
+		if err := writer.Close(); err != nil {
+			log.Printf("failed to close writer: %v", err)
+		}
+	}
+	for _, reader := range b.readers {
+		if err := reader.Close(); err != nil {
+			log.Printf("failed to close reader: %v", err)
+		}
+	}
+	return nil
+}
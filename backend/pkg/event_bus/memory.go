@@ -0,0 +1,240 @@
+package event_bus
+
+import (
+	"context"
+	"sync"
+)
+
+// MEMORY_QUEUE_SIZE is how many messages a group can fall behind before
+// publishing to its topic blocks.
+var MEMORY_QUEUE_SIZE = 4096
+
+// MemoryBus is an EventBus within the process, on channels. Without a WAL,
+// messages still queued when the process dies are lost, and messages
+// published before any group subscribes to their topic are held, up to
+// MEMORY_QUEUE_SIZE, for the first group only.
+type MemoryBus struct {
+	wal *wal // nil without a WAL
+
+	// Publishers hold mu to take an offset and their topic's queues, never
+	// while waiting on a queue, so a consumer can always leave
+	mu       sync.Mutex
+	topicMus map[string]*sync.Mutex             // by topic, held while publishing to keep offsets and queues in order
+	queues   map[string]map[string]*memoryQueue // by topic, then group
+	offsets  map[string]int64                   // next offset, by topic
+	held     map[string][]Message               // published with no group subscribed, by topic
+	closed   bool
+
+	closeOnce  sync.Once
+	closing    chan struct{}  // unblocks publishers waiting on a full queue
+	publishing sync.WaitGroup // publishes still sending to queues
+	done       chan struct{}  // tells consumers to drain their queue and return
+	consumers  sync.WaitGroup
+}
+
+// memoryQueue is a group's queue on a topic. left is closed once the group
+// leaves it, so publishers stop waiting on it.
+type memoryQueue struct {
+	messages chan Message
+	left     chan struct{}
+}
+
+// NewMemoryBus returns an in-process bus. If walDir is set, every message is
+// also appended to a write-ahead log there along with how far each group has
+// got, so a group picks up where it left off after a restart.
+func NewMemoryBus(walDir string) (*MemoryBus, error) {
+	b := &MemoryBus{
+		topicMus: make(map[string]*sync.Mutex),
+		queues:   make(map[string]map[string]*memoryQueue),
+		offsets:  make(map[string]int64),
+		held:     make(map[string][]Message),
+		closing:  make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	if walDir != "" {
+		w, err := openWAL(walDir)
+		if err != nil {
+			return nil, err
+		}
+		b.wal = w
+		for topic, length := range w.lengths {
+			b.offsets[topic] = length
+		}
+	}
+
+	return b, nil
+}
+
+func (b *MemoryBus) Publish(ctx context.Context, topic string, key, value []byte) error {
+	topicMu := b.topicMu(topic)
+	topicMu.Lock()
+	defer topicMu.Unlock()
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrClosed
+	}
+
+	message := Message{Topic: topic, Key: key, Value: value, Offset: b.offsets[topic]}
+	if b.wal != nil {
+		if err := b.wal.append(message); err != nil {
+			b.mu.Unlock()
+			return err
+		}
+	}
+	b.offsets[topic]++
+
+	// The WAL already holds it for whichever group turns up
+	if len(b.queues[topic]) == 0 && b.wal == nil {
+		if len(b.held[topic]) < MEMORY_QUEUE_SIZE {
+			b.held[topic] = append(b.held[topic], message)
+		}
+		b.mu.Unlock()
+		return nil
+	}
+
+	queues := make([]*memoryQueue, 0, len(b.queues[topic]))
+	for _, queue := range b.queues[topic] {
+		queues = append(queues, queue)
+	}
+	// Close waits for this, so consumers don't drain before it is queued
+	b.publishing.Add(1)
+	defer b.publishing.Done()
+	b.mu.Unlock()
+
+	for _, queue := range queues {
+		select {
+		case queue.messages <- message:
+		case <-queue.left:
+			// Its group starts over from the WAL, or loses it without one
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-b.closing:
+			return ErrClosed
+		}
+	}
+	return nil
+}
+
+// topicMu returns the lock a publisher to topic holds.
+func (b *MemoryBus) topicMu(topic string) *sync.Mutex {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	topicMu, ok := b.topicMus[topic]
+	if !ok {
+		topicMu = &sync.Mutex{}
+		b.topicMus[topic] = topicMu
+	}
+	return topicMu
+}
+
+func (b *MemoryBus) Subscribe(topic, group string, handle func(Message) error) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrClosed
+	}
+	if b.queues[topic] == nil {
+		b.queues[topic] = make(map[string]*memoryQueue)
+	}
+	queue, joined := b.queues[topic][group]
+	if !joined {
+		queue = &memoryQueue{
+			messages: make(chan Message, MEMORY_QUEUE_SIZE),
+			left:     make(chan struct{}),
+		}
+		b.queues[topic][group] = queue
+	}
+	end := b.offsets[topic]
+	held := b.held[topic]
+	delete(b.held, topic)
+	b.consumers.Add(1)
+	b.mu.Unlock()
+	defer b.consumers.Done()
+
+	// Catch up on what the group missed while it wasn't running. Anything
+	// after end is already going to the queue
+	if !joined && b.wal != nil {
+		if err := b.wal.replay(topic, group, end, func(message Message) error {
+			return b.deliver(group, message, handle)
+		}); err != nil {
+			return b.leave(topic, group, queue, err)
+		}
+	}
+	for _, message := range held {
+		if err := b.deliver(group, message, handle); err != nil {
+			return b.leave(topic, group, queue, err)
+		}
+	}
+
+	for {
+		select {
+		case message := <-queue.messages:
+			if err := b.deliver(group, message, handle); err != nil {
+				return b.leave(topic, group, queue, err)
+			}
+		case <-b.done:
+			for {
+				select {
+				case message := <-queue.messages:
+					if err := b.deliver(group, message, handle); err != nil {
+						return b.leave(topic, group, queue, err)
+					}
+				default:
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// deliver passes message to handle and, if it succeeds, records that group
+// is past it.
+func (b *MemoryBus) deliver(group string, message Message, handle func(Message) error) error {
+	if err := handle(message); err != nil {
+		return err
+	}
+	if b.wal != nil {
+		b.wal.commit(message.Topic, group, message.Offset+1)
+	}
+	return nil
+}
+
+// leave drops group's queue after handle failed, so subscribing again
+// starts over from the WAL at the failed message. Without a WAL, the failed
+// message and any still queued are lost.
+func (b *MemoryBus) leave(topic, group string, queue *memoryQueue, err error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Consumers sharing the queue may all leave it
+	if b.queues[topic][group] == queue {
+		delete(b.queues[topic], group)
+		close(queue.left)
+	}
+	return err
+}
+
+func (b *MemoryBus) Close() error {
+	var err error
+	b.closeOnce.Do(func() {
+		close(b.closing)
+
+		// Wait out publishes in progress, so consumers drain everything
+		b.mu.Lock()
+		b.closed = true
+		b.mu.Unlock()
+		b.publishing.Wait()
+
+		close(b.done)
+		b.consumers.Wait()
+
+		if b.wal != nil {
+			err = b.wal.close()
+		}
+	})
+	return err
+}
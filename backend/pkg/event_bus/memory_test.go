@@ -0,0 +1,73 @@
+package event_bus
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// A consumer that fails while a publisher waits on its full queue must still
+// be able to leave, and dead-letter from inside its handler on the way.
+func TestMemoryBusFailingConsumerWithFullQueue(t *testing.T) {
+	defer func(size int) { MEMORY_QUEUE_SIZE = size }(MEMORY_QUEUE_SIZE)
+	MEMORY_QUEUE_SIZE = 1
+
+	bus, err := NewMemoryBus("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bus.Close()
+
+	ctx := context.Background()
+	publish := func(value string) error {
+		return bus.Publish(ctx, "events", nil, []byte(value))
+	}
+
+	// Held until the group subscribes, then handled first
+	if err := publish("first"); err != nil {
+		t.Fatal(err)
+	}
+
+	failure := errors.New("store is down")
+	handling := make(chan struct{})
+	release := make(chan struct{})
+	subscribed := make(chan error, 1)
+	go func() {
+		subscribed <- bus.Subscribe("events", "group", func(message Message) error {
+			close(handling)
+			<-release
+			if err := bus.Publish(ctx, "dead_letters", nil, message.Value); err != nil {
+				return err
+			}
+			return failure
+		})
+	}()
+	<-handling
+
+	// Fills the queue, then waits on it
+	if err := publish("second"); err != nil {
+		t.Fatal(err)
+	}
+	published := make(chan error, 1)
+	go func() { published <- publish("third") }()
+	time.Sleep(50 * time.Millisecond)
+
+	close(release)
+	select {
+	case err := <-subscribed:
+		if !errors.Is(err, failure) {
+			t.Fatalf("expected the handler's error, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("consumer couldn't leave while a publisher waited on its queue")
+	}
+	select {
+	case err := <-published:
+		if err != nil {
+			t.Fatalf("publish to a left queue failed: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("publisher kept waiting on a queue whose group left")
+	}
+}
@@ -0,0 +1,447 @@
+package event_bus
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	WAL_EXT    = ".wal"
+	OFFSET_EXT = ".offset"
+)
+
+var (
+	// WAL_SEGMENT_SIZE is how many messages a log segment holds before the
+	// next one is started. Segments every known group is past are deleted.
+	WAL_SEGMENT_SIZE int64 = 10000
+
+	// WAL_FLUSH_INTERVAL is how often committed offsets are written out. A
+	// crash replays at most this much of each group's messages again.
+	WAL_FLUSH_INTERVAL = time.Second
+)
+
+// walRecord is one message in a topic's log, one per line. A message's
+// offset is its segment's base offset plus its line number, from 0.
+type walRecord struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+}
+
+// wal is the MemoryBus write-ahead log: segment files of messages per topic,
+// named by the offset of their first message, and a file per topic and group
+// holding the offset the group has handled up to.
+type wal struct {
+	dir     string
+	lengths map[string]int64 // messages in each topic's log when opened
+
+	mu       sync.Mutex
+	files    map[string]*os.File         // last segment, open for appending, by topic
+	segments map[string][]int64          // base offsets, oldest first, by topic
+	offsets  map[string]map[string]int64 // committed, by topic then group
+	dirty    map[string]map[string]bool  // committed since the last flush, by topic then group
+
+	flushMu sync.Mutex // held while writing offsets and compacting
+	stop    chan struct{}
+	flushed sync.WaitGroup
+}
+
+// openWAL opens the log in dir, creating dir if needed, and starts writing
+// out committed offsets every WAL_FLUSH_INTERVAL.
+func openWAL(dir string) (*wal, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create wal dir %s: %w", dir, err)
+	}
+
+	w := &wal{
+		dir:      dir,
+		lengths:  make(map[string]int64),
+		files:    make(map[string]*os.File),
+		segments: make(map[string][]int64),
+		offsets:  make(map[string]map[string]int64),
+		dirty:    make(map[string]map[string]bool),
+		stop:     make(chan struct{}),
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+WAL_EXT))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		topic, base, ok := parseSegmentName(filepath.Base(path))
+		if !ok {
+			// A log from before segments, which starts at offset 0
+			topic = strings.TrimSuffix(filepath.Base(path), WAL_EXT)
+			if err := os.Rename(path, w.segmentPath(topic, 0)); err != nil {
+				return nil, fmt.Errorf("failed to rename wal for %s: %w", topic, err)
+			}
+		}
+		w.segments[topic] = append(w.segments[topic], base)
+	}
+	for topic, bases := range w.segments {
+		sort.Slice(bases, func(i, j int) bool { return bases[i] < bases[j] })
+		last := bases[len(bases)-1]
+		path := w.segmentPath(topic, last)
+		if err := endLine(path); err != nil {
+			return nil, err
+		}
+		length := last
+		if err := scanRecords(path, func(walRecord) bool {
+			length++
+			return true
+		}); err != nil {
+			return nil, err
+		}
+		w.lengths[topic] = length
+	}
+
+	// Groups from earlier runs hold back compaction until they catch up
+	offsetPaths, err := filepath.Glob(filepath.Join(dir, "*"+OFFSET_EXT))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range offsetPaths {
+		topic, group, ok := strings.Cut(strings.TrimSuffix(filepath.Base(path), OFFSET_EXT), ".")
+		if !ok {
+			continue
+		}
+		w.committedLocked(topic, group)
+	}
+
+	w.flushed.Add(1)
+	go w.flushLoop()
+
+	log.Printf("Opened event bus WAL at %s with %d topic(s)", dir, len(w.lengths))
+	return w, nil
+}
+
+// append writes message to the end of its topic's log, starting a new
+// segment once the last one is full.
+func (w *wal) append(message Message) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	topic := message.Topic
+	bases := w.segments[topic]
+	if len(bases) == 0 || message.Offset-bases[len(bases)-1] >= WAL_SEGMENT_SIZE {
+		if file, ok := w.files[topic]; ok {
+			if err := file.Close(); err != nil {
+				log.Printf("failed to close wal segment for %s: %v", topic, err)
+			}
+			delete(w.files, topic)
+		}
+		w.segments[topic] = append(bases, message.Offset)
+	}
+
+	file, ok := w.files[topic]
+	if !ok {
+		bases = w.segments[topic]
+		var err error
+		file, err = os.OpenFile(w.segmentPath(topic, bases[len(bases)-1]), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("failed to open wal for %s: %w", topic, err)
+		}
+		w.files[topic] = file
+	}
+
+	line, err := json.Marshal(walRecord{Key: message.Key, Value: message.Value})
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to append to wal for %s: %w", topic, err)
+	}
+	return nil
+}
+
+// replay passes topic's messages from group's committed offset up to end to
+// handle. A group new since older segments were deleted starts at the
+// oldest one left.
+func (w *wal) replay(topic, group string, end int64, handle func(Message) error) error {
+	w.mu.Lock()
+	offset := w.committedLocked(topic, group)
+	bases := append([]int64(nil), w.segments[topic]...)
+	w.mu.Unlock()
+
+	if len(bases) > 0 && offset < bases[0] {
+		log.Printf("%s messages before offset %d were compacted before %s subscribed", topic, bases[0], group)
+		offset = bases[0]
+	}
+	if offset >= end {
+		return nil
+	}
+	log.Printf("Replaying %d %s message(s) from the WAL for %s", end-offset, topic, group)
+
+	for i, base := range bases {
+		if base >= end {
+			break
+		}
+		if i+1 < len(bases) && bases[i+1] <= offset {
+			continue
+		}
+
+		next := base
+		var handleErr error
+		err := scanRecords(w.segmentPath(topic, base), func(record walRecord) bool {
+			if next >= end {
+				return false
+			}
+			if next >= offset {
+				message := Message{Topic: topic, Key: record.Key, Value: record.Value, Offset: next}
+				if handleErr = handle(message); handleErr != nil {
+					return false
+				}
+			}
+			next++
+			return true
+		})
+		if handleErr != nil {
+			return handleErr
+		}
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// committed returns the offset group has handled topic up to.
+func (w *wal) committed(topic, group string) int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.committedLocked(topic, group)
+}
+
+// committedLocked is committed with mu held. It also makes group known, so
+// segments it hasn't handled yet are kept.
+func (w *wal) committedLocked(topic, group string) int64 {
+	if offset, ok := w.offsets[topic][group]; ok {
+		return offset
+	}
+
+	offset := int64(0)
+	data, err := os.ReadFile(w.offsetPath(topic, group))
+	if err == nil {
+		offset, err = strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+		if err != nil {
+			log.Printf("ignoring bad wal offset for %s/%s: %v", topic, group, err)
+			offset = 0
+		}
+	}
+
+	if w.offsets[topic] == nil {
+		w.offsets[topic] = make(map[string]int64)
+	}
+	w.offsets[topic][group] = offset
+	return offset
+}
+
+// commit records that group has handled topic up to offset. It is written
+// out on the next flush.
+func (w *wal) commit(topic, group string, offset int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.offsets[topic] == nil {
+		w.offsets[topic] = make(map[string]int64)
+	}
+	w.offsets[topic][group] = offset
+	if w.dirty[topic] == nil {
+		w.dirty[topic] = make(map[string]bool)
+	}
+	w.dirty[topic][group] = true
+}
+
+// flushLoop flushes every WAL_FLUSH_INTERVAL until close.
+func (w *wal) flushLoop() {
+	defer w.flushed.Done()
+
+	ticker := time.NewTicker(WAL_FLUSH_INTERVAL)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.flush()
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// flush writes out the offsets committed since the last flush, then deletes
+// the segments every known group of their topic is past.
+func (w *wal) flush() {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	w.mu.Lock()
+	offsets := make(map[string]map[string]int64)
+	for topic, groups := range w.dirty {
+		offsets[topic] = make(map[string]int64)
+		for group := range groups {
+			offsets[topic][group] = w.offsets[topic][group]
+		}
+	}
+	w.dirty = make(map[string]map[string]bool)
+	w.mu.Unlock()
+
+	for topic, groups := range offsets {
+		for group, offset := range groups {
+			if err := writeOffset(w.offsetPath(topic, group), offset); err != nil {
+				log.Printf("failed to commit wal offset for %s/%s: %v", topic, group, err)
+				// Try again next flush, unless it has moved on since
+				w.commit(topic, group, w.committed(topic, group))
+			}
+		}
+	}
+
+	for topic := range offsets {
+		w.compact(topic)
+	}
+}
+
+// compact deletes topic's segments every known group is past. The last
+// segment, still being appended to, is always kept.
+func (w *wal) compact(topic string) {
+	w.mu.Lock()
+	bases := w.segments[topic]
+	past := int64(-1)
+	for _, offset := range w.offsets[topic] {
+		if past < 0 || offset < past {
+			past = offset
+		}
+	}
+	removable := 0
+	for removable+1 < len(bases) && bases[removable+1] <= past {
+		removable++
+	}
+	removed := append([]int64(nil), bases[:removable]...)
+	w.segments[topic] = bases[removable:]
+	w.mu.Unlock()
+
+	for _, base := range removed {
+		if err := os.Remove(w.segmentPath(topic, base)); err != nil && !os.IsNotExist(err) {
+			log.Printf("failed to delete wal segment %d for %s: %v", base, topic, err)
+		}
+	}
+}
+
+// close stops the flush loop, writes out any offsets still pending and
+// closes the log.
+func (w *wal) close() error {
+	close(w.stop)
+	w.flushed.Wait()
+	w.flush()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var firstErr error
+	for _, file := range w.files {
+		if err := file.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// segmentPath is topic's segment starting at base. Bases are zero padded so
+// segments sort in order.
+func (w *wal) segmentPath(topic string, base int64) string {
+	return filepath.Join(w.dir, fmt.Sprintf("%s.%020d%s", topic, base, WAL_EXT))
+}
+
+func (w *wal) offsetPath(topic, group string) string {
+	return filepath.Join(w.dir, topic+"."+group+OFFSET_EXT)
+}
+
+// parseSegmentName splits a segment's file name into its topic and base
+// offset.
+func parseSegmentName(name string) (string, int64, bool) {
+	topic, base, ok := strings.Cut(strings.TrimSuffix(name, WAL_EXT), ".")
+	if !ok {
+		return "", 0, false
+	}
+	offset, err := strconv.ParseInt(base, 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return topic, offset, true
+}
+
+// writeOffset replaces the offset file at path through a temporary file, so
+// a crash leaves either the old offset or the new one.
+func writeOffset(path string, offset int64) error {
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(strconv.FormatInt(offset, 10)); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// endLine ends a torn last line left by a crash mid-append, so the next
+// record starts on its own line.
+func endLine(path string) error {
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || info.Size() == 0 {
+		return err
+	}
+	last := make([]byte, 1)
+	if _, err := file.ReadAt(last, info.Size()-1); err != nil {
+		return err
+	}
+	if last[0] != '\n' {
+		_, err = file.WriteAt([]byte{'\n'}, info.Size())
+	}
+	return err
+}
+
+// scanRecords passes each record in the log at path to fn until it returns
+// false. Lines that don't decode, such as a torn one, are skipped.
+func scanRecords(path string, fn func(walRecord) bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var record walRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			log.Printf("skipping bad wal record in %s: %v", path, err)
+			continue
+		}
+		if !fn(record) {
+			break
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,144 @@
+package event_bus
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// consumeAll subscribes group to topic on bus until want messages are
+// handled, and returns their values.
+func consumeAll(t *testing.T, bus *MemoryBus, topic, group string, want int) []string {
+	t.Helper()
+
+	values := make(chan string, want)
+	go bus.Subscribe(topic, group, func(message Message) error {
+		values <- string(message.Value)
+		return nil
+	})
+
+	var got []string
+	for len(got) < want {
+		select {
+		case value := <-values:
+			got = append(got, value)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%s got %v, want %d message(s)", group, got, want)
+		}
+	}
+	return got
+}
+
+// walFiles lists the files in dir with ext.
+func walFiles(t *testing.T, dir, ext string) []string {
+	t.Helper()
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+ext))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, path := range paths {
+		names = append(names, filepath.Base(path))
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestWALCompaction(t *testing.T) {
+	defer func(size int64) { WAL_SEGMENT_SIZE = size }(WAL_SEGMENT_SIZE)
+	WAL_SEGMENT_SIZE = 2
+
+	for _, tc := range []struct {
+		name string
+		slow string // offset another group committed in an earlier run
+		want []string
+	}{
+		{
+			name: "every group past",
+			want: []string{"events.00000000000000000004.wal"},
+		},
+		{
+			name: "a group still behind",
+			slow: "1",
+			want: []string{
+				"events.00000000000000000000.wal",
+				"events.00000000000000000002.wal",
+				"events.00000000000000000004.wal",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tc.slow != "" {
+				if err := os.WriteFile(filepath.Join(dir, "events.slow"+OFFSET_EXT), []byte(tc.slow), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			bus, err := NewMemoryBus(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, value := range []string{"0", "1", "2", "3", "4"} {
+				if err := bus.Publish(context.Background(), "events", nil, []byte(value)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			consumeAll(t, bus, "events", "group", 5)
+			if err := bus.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := walFiles(t, dir, WAL_EXT); strings.Join(got, " ") != strings.Join(tc.want, " ") {
+				t.Fatalf("segments = %v, want %v", got, tc.want)
+			}
+			if tmp := walFiles(t, dir, ".tmp"); len(tmp) != 0 {
+				t.Fatalf("temporary offset files left behind: %v", tmp)
+			}
+			data, err := os.ReadFile(filepath.Join(dir, "events.group"+OFFSET_EXT))
+			if err != nil || string(data) != "5" {
+				t.Fatalf("committed offset = %q (%v), want 5", data, err)
+			}
+
+			// The group picks up after what it handled, across segments
+			bus, err = NewMemoryBus(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer bus.Close()
+			if err := bus.Publish(context.Background(), "events", nil, []byte("5")); err != nil {
+				t.Fatal(err)
+			}
+			if got := consumeAll(t, bus, "events", "group", 1); got[0] != "5" {
+				t.Fatalf("after a restart the group got %v, want [5]", got)
+			}
+		})
+	}
+}
+
+// A log written before segments is read as the first segment.
+func TestWALOpensLogWithoutSegments(t *testing.T) {
+	dir := t.TempDir()
+	log := `{"key":null,"value":"MA=="}` + "\n" + `{"key":null,"value":"MQ=="}` + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "events"+WAL_EXT), []byte(log), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	bus, err := NewMemoryBus(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bus.Close()
+
+	if got := consumeAll(t, bus, "events", "group", 2); strings.Join(got, " ") != "0 1" {
+		t.Fatalf("replayed %v, want [0 1]", got)
+	}
+	if got := walFiles(t, dir, WAL_EXT); len(got) != 1 || got[0] != "events.00000000000000000000.wal" {
+		t.Fatalf("segments = %v", got)
+	}
+}
@@ -0,0 +1,55 @@
+package gsi
+
+import (
+	"crypto/subtle"
+	"log"
+
+	"github.com/LukeyR/CS2-GameStateIntegration/pkg/cs2gsi/structs"
+	"github.com/ukpabik/CSYou/pkg/config"
+	"github.com/ukpabik/CSYou/pkg/shared"
+)
+
+// AUTH_TOKENS holds the accepted GSI auth tokens. When empty, every payload is
+// accepted.
+var AUTH_TOKENS []config.AuthToken
+
+// authenticate checks the payload's auth token against AUTH_TOKENS, recording
+// a rejection if it is missing or unknown.
+func authenticate(gsiEvent *structs.GSIEvent, payload *rawPayload) (config.AuthToken, bool) {
+	if len(AUTH_TOKENS) == 0 {
+		return config.AuthToken{}, true
+	}
+
+	token := ""
+	if payload.Auth != nil {
+		token = payload.Auth.Token
+	}
+
+	providerSteamID := ""
+	if gsiEvent.Provider != nil {
+		providerSteamID = gsiEvent.Provider.SteamID
+	}
+
+	reason := shared.AUTH_INVALID_TOKEN
+	if token == "" {
+		reason = shared.AUTH_MISSING_TOKEN
+	} else {
+		for _, t := range AUTH_TOKENS {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(t.Token)) != 1 {
+				continue
+			}
+			if t.SteamID == "" || t.SteamID == providerSteamID {
+				return t, true
+			}
+			// Teammates may share a token, each bound to their own SteamID
+			reason = shared.AUTH_STEAMID_MISMATCH
+		}
+	}
+
+	shared.AuthRejections.Record(reason, providerSteamID, token)
+	log.Printf("rejected GSI payload from %q: %s", providerSteamID, reason)
+
+	pushLog("Auth Rejected", nil)
+
+	return config.AuthToken{}, false
+}
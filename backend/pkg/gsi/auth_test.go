@@ -0,0 +1,48 @@
+package gsi
+
+import (
+	"testing"
+
+	"github.com/LukeyR/CS2-GameStateIntegration/pkg/cs2gsi/structs"
+	"github.com/ukpabik/CSYou/pkg/config"
+)
+
+func TestAuthenticate(t *testing.T) {
+	defer func(tokens []config.AuthToken) { AUTH_TOKENS = tokens }(AUTH_TOKENS)
+
+	shared := &config.Config{AuthTokens: []config.AuthToken{
+		{Token: "team_secret", Owner: "alice", SteamID: "76561197960287930"},
+		{Token: "team_secret", Owner: "bob", SteamID: "76561197960287931"},
+	}}
+	legacy := &config.Config{SteamID: "76561197960287930", AuthToken: "secret"}
+
+	for _, tc := range []struct {
+		name     string
+		cfg      *config.Config
+		steamID  string
+		token    string
+		accepted bool
+		owner    string
+	}{
+		{"first teammate on a shared token", shared, "76561197960287930", "team_secret", true, "alice"},
+		{"second teammate on a shared token", shared, "76561197960287931", "team_secret", true, "bob"},
+		{"stranger on a shared token", shared, "76561197960287932", "team_secret", false, ""},
+		{"wrong token", shared, "76561197960287930", "other", false, ""},
+		{"single token from steam_id", legacy, "76561197960287930", "secret", true, "76561197960287930"},
+		{"single token from another client", legacy, "76561197960287931", "secret", false, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			AUTH_TOKENS = tc.cfg.Tokens()
+			gsiEvent := &structs.GSIEvent{Provider: &structs.Provider{SteamID: tc.steamID}}
+			payload := &rawPayload{}
+			payload.Auth = &struct {
+				Token string `json:"token"`
+			}{Token: tc.token}
+
+			token, accepted := authenticate(gsiEvent, payload)
+			if accepted != tc.accepted || token.Owner != tc.owner {
+				t.Fatalf("got accepted = %v, owner %q; want %v, %q", accepted, token.Owner, tc.accepted, tc.owner)
+			}
+		})
+	}
+}
@@ -1,52 +1,64 @@
 package gsi
 
 import (
-	"encoding/json"
 	"fmt"
 	"log"
-	"os"
 	"time"
 
 	"github.com/LukeyR/CS2-GameStateIntegration/pkg/cs2gsi"
 	"github.com/LukeyR/CS2-GameStateIntegration/pkg/cs2gsi/events"
 	"github.com/LukeyR/CS2-GameStateIntegration/pkg/cs2gsi/structs"
-	"github.com/google/uuid"
 	"github.com/ukpabik/CSYou/pkg/api"
 	"github.com/ukpabik/CSYou/pkg/api/model"
+	"github.com/ukpabik/CSYou/pkg/config"
 	"github.com/ukpabik/CSYou/pkg/kafka_io"
+	"github.com/ukpabik/CSYou/pkg/match_events"
 	"github.com/ukpabik/CSYou/pkg/player_events"
 	"github.com/ukpabik/CSYou/pkg/shared"
 )
 
 const GSI_PORT = 3000
 
-// Config struct for reading config.json
-type Config struct {
-	SteamID string `json:"steam_id"`
-}
+// PLAYER_IDS are the players whose own clients are tracked. When empty, every
+// authenticated client's own player is tracked.
+var PLAYER_IDS map[string]bool
+
+// RECORDER_CONFIG controls recording of raw payloads, see StartRecorder.
+var RECORDER_CONFIG config.RecorderConfig
 
-var STEAM_ID string
+var configLoaded bool
 
-// LoadConfig loads the steam_id from config.json
+// LoadConfig loads the tracked SteamIDs and GSI auth tokens from config.json
 func LoadConfig() {
-	file, err := os.Open("../config.json")
+	cfg, err := config.Load(config.DEFAULT_PATH)
 	if err != nil {
-		log.Fatalf("failed to open config.json: %v", err)
+		log.Fatalf("%v", err)
+	}
+
+	PLAYER_IDS = cfg.PlayerIDs()
+	AUTH_TOKENS = cfg.Tokens()
+	SPECTATOR_MODE = cfg.SpectatorMode
+	RECORDER_CONFIG = cfg.Recorder
+	if cfg.TradeWindow > 0 {
+		player_events.TRADE_WINDOW = int64(cfg.TradeWindow)
 	}
-	defer file.Close()
+	configLoaded = true
 
-	decoder := json.NewDecoder(file)
-	var config Config
-	if err := decoder.Decode(&config); err != nil {
-		log.Fatalf("failed to parse config.json: %v", err)
+	if len(PLAYER_IDS) == 0 {
+		log.Println("No steam_id configured, tracking the player of every authenticated client")
+	} else {
+		log.Printf("Loaded %d SteamID(s) from config", len(PLAYER_IDS))
 	}
 
-	if config.SteamID == "" {
-		log.Fatalf("steam_id must be set in config.json")
+	if len(AUTH_TOKENS) == 0 {
+		log.Println("WARNING: no auth_token configured, accepting GSI payloads from any client")
+	} else {
+		log.Printf("Loaded %d GSI auth token(s) from config", len(AUTH_TOKENS))
 	}
 
-	STEAM_ID = config.SteamID
-	log.Printf("Loaded SteamID from config: %s", STEAM_ID)
+	if SPECTATOR_MODE {
+		log.Println("Spectator mode enabled, tracking every player in allplayers")
+	}
 }
 
 // InitializeEventHandlers initializes all event handlers for every captured event.
@@ -54,69 +66,295 @@ func LoadConfig() {
 // NOTE: Make sure you update your `config.json` with your actual SteamID
 // before running this, otherwise no events will be tracked.
 func InitializeEventHandlers() {
-	if STEAM_ID == "" {
+	if !configLoaded {
 		LoadConfig()
 	}
 
-	cs2gsi.RegisterGlobalHandler(func(gsiEvent *structs.GSIEvent, gameEvent events.GameEventDetails) {
-		if shared.PlayerID == "" {
-			shared.PlayerID = STEAM_ID
+	StartRecorder(RECORDER_CONFIG)
+
+	cs2gsi.RegisterGlobalHandler(globalHandler)
+	cs2gsi.RegisterNonEventHandler(nonEventHandler)
+}
+
+// globalHandler is called by cs2gsi for every recognised game event.
+func globalHandler(gsiEvent *structs.GSIEvent, gameEvent events.GameEventDetails) {
+	handleGameEvent(gsiEvent, gameEvent, time.Now())
+}
+
+// nonEventHandler is called by cs2gsi for payloads without a recognised event.
+// Phase and round changes have no event of their own, so these payloads still
+// go through the pipeline.
+func nonEventHandler(gsiEvent *structs.GSIEvent) {
+	handleNonEvent(gsiEvent, time.Now())
+}
+
+// handleGameEvent handles a game event from a payload that arrived at
+// receivedAt.
+func handleGameEvent(gsiEvent *structs.GSIEvent, gameEvent events.GameEventDetails, receivedAt time.Time) {
+	if !payloads.handle(gsiEvent, receivedAt, handlePayload) {
+		return
+	}
+
+	pushLog(events.EnumToEventName[gameEvent.EventType], nil)
+}
+
+// handleNonEvent handles a payload without a game event that arrived at
+// receivedAt.
+func handleNonEvent(gsiEvent *structs.GSIEvent, receivedAt time.Time) {
+	payloads.handle(gsiEvent, receivedAt, handlePayload)
+}
+
+// handlePayload authenticates a payload and pushes it through the pipeline.
+// It runs once per payload and reports whether the payload was accepted.
+func handlePayload(gsiEvent *structs.GSIEvent, receivedAt time.Time) bool {
+	// Every payload is recorded, rejected ones too, so filtering can be
+	// reproduced. It is written once the match it belongs to is known
+	var session *Session
+	if payloadRecorder != nil {
+		recorded := RecordedPayload{
+			ReceivedAt: receivedAt.UnixMilli(),
+			Body:       redactToken(gsiEvent.GetOriginalRequestFlat()),
 		}
+		defer func() {
+			if session != nil {
+				session.mu.Lock()
+				recorded.MatchID = session.Match.MatchID
+				session.mu.Unlock()
+			}
+			payloadRecorder.record(recorded)
+		}()
+	}
+
+	payload := parsePayload(gsiEvent)
+	token, ok := authenticate(gsiEvent, payload)
+	if !ok {
+		return false
+	}
 
-		if gsiEvent.Player == nil || gsiEvent.Player.Steamid != shared.PlayerID || gsiEvent.Round == nil {
-			return
+	if gsiEvent.Provider == nil || gsiEvent.Player == nil || gsiEvent.CSMap == nil || gsiEvent.Round == nil {
+		return false
+	}
+
+	// In spectator mode every tracked player in allplayers gets their own
+	// stream, otherwise only the client's own player is tracked. When dead,
+	// the player block belongs to whoever is being spectated.
+	steamID := gsiEvent.Provider.SteamID
+	views := []*structs.GSIEvent{gsiEvent}
+	if SPECTATOR_MODE && len(payload.AllPlayers) > 0 {
+		views = spectatorViews(gsiEvent, payload)
+		if len(views) == 0 {
+			return false
 		}
+	} else if gsiEvent.Player.Steamid != steamID || (len(PLAYER_IDS) > 0 && !PLAYER_IDS[steamID]) {
+		return false
+	}
 
-		eventLog := &model.Log{
-			EventType: events.EnumToEventName[gameEvent.EventType],
-			Time:      time.Now().Format("2006-01-02 15:04:05.000"),
+	session = sessions.get(steamID, token.Token, token.Owner)
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	gap := receivedAt.Sub(session.LastSeen)
+	reconnected := !session.LastSeen.IsZero() && gap > RECONNECT_TIMEOUT
+	session.LastSeen = receivedAt
+
+	prevState := session.Match.State
+	matchEvents := session.Match.Update(gsiEvent, steamID, receivedAt)
+	matchStarted := false
+	for _, me := range matchEvents {
+		if me.Type != shared.MATCH_STARTED {
+			continue
 		}
+		matchStarted = true
+		log.Printf("New match started for %s: %s on map %s", steamID, me.MatchID, me.Map)
 
-		// Send log to frontend
-		api.PushLog(*eventLog)
+		// Stats are already counting when joining a match in progress
+		if me.JoinedInProgress {
+			session.Tracker.Rebaseline()
+		} else {
+			session.Tracker.Reset()
+		}
+	}
+
+	switch {
+	case matchStarted:
+	case reconnected:
+		log.Printf("Client %s reconnected after %s, rebaselining stats", steamID, gap.Round(time.Second))
+		session.Tracker.Rebaseline()
+	case prevState == match_events.STATE_WARMUP && session.Match.State == match_events.STATE_LIVE:
+		// Stats are wiped when warmup ends
+		session.Tracker.Reset()
+	}
+
+	publishMatchEvents(matchEvents)
+	for _, view := range views {
+		publishPlayerEvents(session, view, payload)
+	}
 
-		if gsiEvent.CSMap.Name != shared.LastMap || gsiEvent.Round.Phase == "gameover" {
-			shared.CurrentMatchID = uuid.New().String()
-			shared.LastMap = gsiEvent.CSMap.Name
-			shared.LastRound = 0
-			log.Printf("New match started: %s on map %s", shared.CurrentMatchID, shared.LastMap)
+	// Track last round
+	session.LastRound = gsiEvent.CSMap.Round
+
+	return true
+}
+
+// bombEventTypes are the frontend log names of bomb actions.
+var bombEventTypes = map[string]string{
+	shared.BOMB_PICKED_UP: "Bomb Picked Up",
+	shared.BOMB_DROPPED:   "Bomb Dropped",
+	shared.BOMB_PLANTING:  "Bomb Planting",
+	shared.BOMB_PLANTED:   "Bomb Planted",
+	shared.BOMB_DEFUSING:  "Bomb Defusing",
+	shared.BOMB_DEFUSED:   "Bomb Defused",
+	shared.BOMB_EXPLODED:  "Bomb Exploded",
+}
+
+// badgeEventTypes are the frontend log names of round badges.
+var badgeEventTypes = map[string]string{
+	shared.BADGE_ACE:          "Ace",
+	shared.BADGE_OPENING_KILL: "Opening Kill",
+	shared.BADGE_ENTRY_DEATH:  "Entry Death",
+	shared.BADGE_EXIT_FRAG:    "Exit Frag",
+}
+
+// clutchEventTypes are the frontend log names of clutch actions.
+var clutchEventTypes = map[string]string{
+	shared.CLUTCH_STARTED: "Clutch Started",
+	shared.CLUTCH_KILL:    "Clutch Kill",
+	shared.CLUTCH_WON:     "Clutch Won",
+	shared.CLUTCH_LOST:    "Clutch Lost",
+}
+
+// pushLog sends an event to the frontend's live log. data is only set for
+// events the frontend shows the details of.
+func pushLog(eventType string, data any) {
+	api.PushLog(model.Log{
+		EventType: eventType,
+		Time:      time.Now().Format("2006-01-02 15:04:05.000"),
+		Data:      data,
+	})
+}
+
+// publishMatchEvents publishes match lifecycle events to Kafka.
+func publishMatchEvents(matchEvents []*shared.MatchEvent) {
+	for _, me := range matchEvents {
+		eventType := "Match Started"
+		if me.Type == shared.MATCH_ENDED {
+			eventType = "Match Ended"
+		}
+
+		pushLog(eventType, nil)
+		if err := kafka_io.WriteMatchEvent(me, me.SteamID); err != nil {
+			log.Printf("failed to write match event to kafka: %v", err)
 		}
+	}
+}
 
-		playerEvent := shared.BundlePlayerEvent(gsiEvent, &gameEvent)
+// publishPlayerEvents bundles the player state and any new kills or deaths
+// from a single-player view of a payload and publishes them to Kafka.
+func publishPlayerEvents(session *Session, gsiEvent *structs.GSIEvent, payload *rawPayload) {
+	matchID := session.Match.MatchID
+	playerEvent := shared.BundlePlayerEvent(matchID, gsiEvent)
 
-		// Publish player event to Kafka
-		if err := kafka_io.WritePlayerEvent(playerEvent, gsiEvent.Player.Steamid); err != nil {
-			log.Printf("failed to write player event to kafka: %v", err)
+	// Publish player event to Kafka
+	if err := kafka_io.WritePlayerEvent(playerEvent, gsiEvent.Player.Steamid); err != nil {
+		log.Printf("failed to write player event to kafka: %v", err)
+	}
+
+	killEvents := session.Tracker.DetectKillEvents(matchID, gsiEvent)
+	for _, ke := range killEvents {
+		if ke.ActiveGun.Type == "C4" {
+			continue
 		}
 
-		killEvents := player_events.DetectKillEvents(shared.CurrentMatchID, gsiEvent)
-		for _, ke := range killEvents {
-			if ke.ActiveGun.Type == "C4" {
-				continue
-			}
+		pushLog("Player Kill", nil)
+		if err := kafka_io.WriteKillEvent(ke, gsiEvent.Player.Steamid); err != nil {
+			log.Printf("failed to write kill event to kafka: %v", err)
+		}
+	}
 
-			killEventLog := &model.Log{
-				EventType: "Player Kill",
-				Time:      time.Now().Format("2006-01-02 15:04:05.000"),
-			}
+	// Derived from the kills, so after them
+	multiKill, badgeEvents := session.Tracker.DetectRoundBadges(matchID, gsiEvent, payload.teamsAlive())
+	if multiKill != nil {
+		pushLog("Multi Kill", multiKill)
+		if err := kafka_io.WriteMultiKillEvent(multiKill, gsiEvent.Player.Steamid); err != nil {
+			log.Printf("failed to write multi-kill event to kafka: %v", err)
+		}
+	}
+	for _, be := range badgeEvents {
+		pushLog(badgeEventTypes[be.Badge], be)
+		if err := kafka_io.WriteBadgeEvent(be, gsiEvent.Player.Steamid); err != nil {
+			log.Printf("failed to write badge event to kafka: %v", err)
+		}
+	}
 
-			// Send log to frontend
-			api.PushLog(*killEventLog)
-			if err := kafka_io.WriteKillEvent(ke, gsiEvent.Player.Steamid); err != nil {
-				log.Printf("failed to write kill event to kafka: %v", err)
-			}
+	deathEvents := session.Tracker.DetectDeathEvents(matchID, gsiEvent, payload.roundClock())
+	for _, de := range deathEvents {
+		pushLog("Player Death", nil)
+		if err := kafka_io.WriteDeathEvent(de, gsiEvent.Player.Steamid); err != nil {
+			log.Printf("failed to write death event to kafka: %v", err)
 		}
+	}
 
-		// Track last round
-		shared.LastRound = gsiEvent.CSMap.Round
-	})
+	// Opening duels and trades, from this payload's kills and deaths
+	duelEvents := session.Tracker.DetectDuelEvents(matchID, gsiEvent, killEvents, deathEvents, payload.teamsAlive())
+	for _, de := range duelEvents {
+		if err := kafka_io.WriteDuelEvent(de, gsiEvent.Player.Steamid); err != nil {
+			log.Printf("failed to write duel event to kafka: %v", err)
+		}
+	}
 
-	cs2gsi.RegisterNonEventHandler(func(gsiEvent *structs.GSIEvent) {
-		// fmt.Printf("(%v) #N/A %v\n",
-		// 	time.Now().Format("2006-01-02 15:04:05.000"),
-		// 	gsiEvent.GetOriginalRequestFlat(),
-		// )
-	})
+	assistEvents := session.Tracker.DetectAssistEvents(matchID, gsiEvent)
+	for _, ae := range assistEvents {
+		eventType := "Player Assist"
+		if ae.FlashAssist {
+			eventType = "Player Flash Assist"
+		}
+		pushLog(eventType, nil)
+		if err := kafka_io.WriteAssistEvent(ae, gsiEvent.Player.Steamid); err != nil {
+			log.Printf("failed to write assist event to kafka: %v", err)
+		}
+	}
+
+	if de := session.Tracker.DetectDamageEvents(matchID, gsiEvent, payload.roundClock()); de != nil {
+		if err := kafka_io.WriteDamageEvent(de, gsiEvent.Player.Steamid); err != nil {
+			log.Printf("failed to write damage event to kafka: %v", err)
+		}
+	}
+
+	clutchEvents := session.Tracker.DetectClutchEvents(matchID, gsiEvent, payload.teamsAlive())
+	for _, ce := range clutchEvents {
+		pushLog(clutchEventTypes[ce.Action], ce)
+		if err := kafka_io.WriteClutchEvent(ce, gsiEvent.Player.Steamid); err != nil {
+			log.Printf("failed to write clutch event to kafka: %v", err)
+		}
+	}
+
+	bombEvents := session.Tracker.DetectBombEvents(matchID, gsiEvent, payload.Bomb)
+	for _, be := range bombEvents {
+		pushLog(bombEventTypes[be.Action], nil)
+		if err := kafka_io.WriteBombEvent(be, gsiEvent.Player.Steamid); err != nil {
+			log.Printf("failed to write bomb event to kafka: %v", err)
+		}
+	}
+
+	utilityEvents := session.Tracker.DetectUtilityEvents(matchID, gsiEvent)
+	for _, ue := range utilityEvents {
+		if err := kafka_io.WriteUtilityEvent(ue, gsiEvent.Player.Steamid); err != nil {
+			log.Printf("failed to write utility event to kafka: %v", err)
+		}
+	}
+
+	// Utility goes first, the round summary includes its tallies
+	if summary := session.Tracker.DetectRoundSummary(matchID, gsiEvent); summary != nil {
+		pushLog("Round Summary", nil)
+		if err := kafka_io.WriteRoundSummary(summary, gsiEvent.Player.Steamid); err != nil {
+			log.Printf("failed to write round summary to kafka: %v", err)
+		}
+	}
+
+	// The economy is read off the round the summary tracks
+	if te := session.Tracker.DetectTeamEconomy(matchID, gsiEvent); te != nil {
+		pushLog("Team Economy", te)
+	}
 }
 
 // Listen starts up the GSI server to listen for POST requests (with event data).
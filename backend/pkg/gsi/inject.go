@@ -0,0 +1,60 @@
+package gsi
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/LukeyR/CS2-GameStateIntegration/pkg/cs2gsi/checkers"
+	"github.com/LukeyR/CS2-GameStateIntegration/pkg/cs2gsi/events"
+	"github.com/LukeyR/CS2-GameStateIntegration/pkg/cs2gsi/structs"
+)
+
+// eventCheckers mirrors the checkers cs2gsi runs on every POST, in the same
+// order, so injected payloads raise the same game events as live ones.
+var eventCheckers = []func(*structs.GSIEvent) *events.GameEventDetails{
+	checkers.CheckEventHeartbeat,
+	checkers.CheckEventWeaponsChanged,
+	checkers.CheckEventPlayerActivityChanged,
+	checkers.CheckEventPlayerAliveStatusChanged,
+	checkers.CheckEventPlayerHealthChanged,
+	checkers.CheckEventPlayerArmourChanged,
+	checkers.CheckEventBombPlanted,
+	checkers.CheckEventBombExploded,
+	checkers.CheckEventBombDefused,
+}
+
+// Inject feeds a raw GSI request body through the same handlers that
+// InitializeEventHandlers registers, without going through HTTP. The payload
+// is handled as if it arrived at receivedAt, so match timings and reconnects
+// are worked out as they were when it was recorded.
+func Inject(body []byte, receivedAt time.Time) (err error) {
+	gsiEvent, err := structs.NewGSIEvent(string(body))
+	if err != nil {
+		return fmt.Errorf("failed to parse GSI payload: %w", err)
+	}
+
+	// The checkers assume a full player block, like the HTTP server we
+	// recover instead of crashing on partial payloads.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic while handling GSI payload: %v", r)
+		}
+	}()
+
+	var gameEvents []events.GameEventDetails
+	for _, checker := range eventCheckers {
+		if res := checker(gsiEvent); res != nil {
+			gameEvents = append(gameEvents, *res)
+		}
+	}
+
+	if len(gameEvents) == 0 {
+		handleNonEvent(gsiEvent, receivedAt)
+		return nil
+	}
+
+	for _, gameEvent := range gameEvents {
+		handleGameEvent(gsiEvent, gameEvent, receivedAt)
+	}
+	return nil
+}
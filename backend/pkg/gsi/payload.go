@@ -0,0 +1,113 @@
+package gsi
+
+import (
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/LukeyR/CS2-GameStateIntegration/pkg/cs2gsi/structs"
+	"github.com/ukpabik/CSYou/pkg/player_events"
+)
+
+// rawPayload holds the parts of a GSI request body that the cs2gsi library
+// does not decode into structs.GSIEvent.
+type rawPayload struct {
+	Auth *struct {
+		Token string `json:"token"`
+	} `json:"auth"`
+
+	// AllPlayers is only sent to spectators and GOTV, keyed by SteamID.
+	AllPlayers map[string]*structs.Player `json:"allplayers"`
+
+	// Bomb is only sent to spectators and GOTV.
+	Bomb *player_events.Bomb `json:"bomb"`
+
+	PhaseCountdowns *struct {
+		Phase       string `json:"phase"`
+		PhaseEndsIn string `json:"phase_ends_in"`
+	} `json:"phase_countdowns"`
+}
+
+// parsePayload decodes the extra fields from the original request body.
+func parsePayload(gsiEvent *structs.GSIEvent) *rawPayload {
+	payload := &rawPayload{}
+	if gsiEvent.OriginalData == "" {
+		return payload
+	}
+	_ = json.Unmarshal([]byte(gsiEvent.OriginalData), payload)
+	return payload
+}
+
+// roundClock returns the round timer from phase_countdowns.
+func (p *rawPayload) roundClock() player_events.RoundClock {
+	clock := player_events.RoundClock{Seconds: -1}
+	if p.PhaseCountdowns == nil {
+		return clock
+	}
+
+	clock.Phase = p.PhaseCountdowns.Phase
+	if seconds, err := strconv.ParseFloat(p.PhaseCountdowns.PhaseEndsIn, 64); err == nil {
+		clock.Seconds = seconds
+	}
+	return clock
+}
+
+// teamsAlive counts the alive players on each side in allplayers.
+func (p *rawPayload) teamsAlive() player_events.TeamsAlive {
+	alive := player_events.TeamsAlive{Known: len(p.AllPlayers) > 0}
+	for _, player := range p.AllPlayers {
+		if player == nil || player.State.Health == nil || *player.State.Health == 0 {
+			continue
+		}
+		switch player.Team {
+		case "CT":
+			alive.CT++
+		case "T":
+			alive.T++
+		}
+	}
+	return alive
+}
+
+// payloadGuardSize is how many recent payloads are remembered. It only needs
+// to cover the number of requests being handled concurrently.
+const payloadGuardSize = 64
+
+type handledPayload struct {
+	event    *structs.GSIEvent
+	accepted bool
+}
+
+// payloadGuard makes sure each payload is processed once. The cs2gsi library
+// calls the global handler once per game event it detects, so the same
+// *structs.GSIEvent is usually delivered several times in a row.
+type payloadGuard struct {
+	mu     sync.Mutex
+	recent [payloadGuardSize]handledPayload
+	next   int
+}
+
+var payloads = &payloadGuard{}
+
+// handle runs process the first time gsiEvent is seen and returns its result;
+// later calls with the same payload return the cached result.
+func (g *payloadGuard) handle(gsiEvent *structs.GSIEvent, receivedAt time.Time, process func(*structs.GSIEvent, time.Time) bool) bool {
+	g.mu.Lock()
+	for _, p := range g.recent {
+		if p.event == gsiEvent {
+			g.mu.Unlock()
+			return p.accepted
+		}
+	}
+	g.mu.Unlock()
+
+	accepted := process(gsiEvent, receivedAt)
+
+	g.mu.Lock()
+	g.recent[g.next] = handledPayload{event: gsiEvent, accepted: accepted}
+	g.next = (g.next + 1) % payloadGuardSize
+	g.mu.Unlock()
+
+	return accepted
+}
@@ -0,0 +1,292 @@
+package gsi
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ukpabik/CSYou/pkg/config"
+)
+
+const (
+	recorderQueueSize     = 4096
+	recorderFlushInterval = time.Second // most a crash can lose
+	recorderIdleTimeout   = 5 * time.Minute
+	defaultRecorderDir    = "../recordings"
+	defaultRecorderMB     = 64
+)
+
+// RecordedPayload is one line of a recording: a raw GSI request body, with
+// its auth token redacted, and when it arrived.
+type RecordedPayload struct {
+	ReceivedAt int64           `json:"received_at"` // unix milliseconds
+	MatchID    string          `json:"match_id"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// recorder appends raw payloads to gzip'd NDJSON files, one series per match,
+// rotating once a file reaches maxBytes. Writes happen on a background
+// goroutine; when the queue is full payloads are dropped rather than
+// blocking ingestion.
+type recorder struct {
+	dir      string
+	maxBytes int64
+	queue    chan RecordedPayload
+	done     chan struct{}
+
+	mu      sync.Mutex
+	dropped int64
+	closed  bool
+}
+
+// recording is the currently open file of one match.
+type recording struct {
+	matchID  string
+	seq      int
+	file     *os.File
+	counter  *countingWriter
+	gz       *gzip.Writer
+	buf      *bufio.Writer
+	lastUsed time.Time
+	dirty    bool // written to since the last flush
+}
+
+var payloadRecorder *recorder
+
+// REDACTED_TOKEN replaces the auth token in recordings.
+const REDACTED_TOKEN = "redacted"
+
+// StartRecorder starts recording raw payloads if enabled in cfg.
+func StartRecorder(cfg config.RecorderConfig) {
+	if !cfg.Enabled {
+		return
+	}
+
+	dir := cfg.Dir
+	if dir == "" {
+		dir = defaultRecorderDir
+	}
+	maxMB := cfg.MaxMB
+	if maxMB <= 0 {
+		maxMB = defaultRecorderMB
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Printf("unable to create recordings directory, recorder disabled: %v", err)
+		return
+	}
+
+	payloadRecorder = &recorder{
+		dir:      dir,
+		maxBytes: int64(maxMB) << 20,
+		queue:    make(chan RecordedPayload, recorderQueueSize),
+		done:     make(chan struct{}),
+	}
+	go payloadRecorder.run()
+
+	log.Printf("Recording raw GSI payloads to %s", dir)
+}
+
+// CloseRecorder flushes and closes every open recording.
+func CloseRecorder() {
+	if payloadRecorder == nil {
+		return
+	}
+
+	payloadRecorder.mu.Lock()
+	if payloadRecorder.closed {
+		payloadRecorder.mu.Unlock()
+		return
+	}
+	payloadRecorder.closed = true
+	close(payloadRecorder.queue)
+	payloadRecorder.mu.Unlock()
+
+	<-payloadRecorder.done
+}
+
+// record queues a payload without blocking.
+func (r *recorder) record(p RecordedPayload) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return
+	}
+
+	select {
+	case r.queue <- p:
+	default:
+		r.dropped++
+		if r.dropped%100 == 1 {
+			log.Printf("recorder queue full, %d payload(s) dropped so far", r.dropped)
+		}
+	}
+}
+
+func (r *recorder) run() {
+	defer close(r.done)
+
+	open := make(map[string]*recording)
+	ticker := time.NewTicker(recorderFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case p, ok := <-r.queue:
+			if !ok {
+				for _, rec := range open {
+					rec.close()
+				}
+				return
+			}
+			if err := r.write(open, p); err != nil {
+				log.Printf("failed to record payload: %v", err)
+			}
+		case now := <-ticker.C:
+			for matchID, rec := range open {
+				if now.Sub(rec.lastUsed) > recorderIdleTimeout {
+					rec.close()
+					delete(open, matchID)
+					continue
+				}
+				rec.flush()
+			}
+		}
+	}
+}
+
+func (r *recorder) write(open map[string]*recording, p RecordedPayload) error {
+	rec, ok := open[p.MatchID]
+	if ok && rec.counter.n >= r.maxBytes {
+		rec.close()
+		next, err := r.openRecording(p.MatchID, rec.seq+1)
+		if err != nil {
+			delete(open, p.MatchID)
+			return err
+		}
+		rec = next
+		open[p.MatchID] = rec
+	}
+	if !ok {
+		var err error
+		if rec, err = r.openRecording(p.MatchID, 0); err != nil {
+			return err
+		}
+		open[p.MatchID] = rec
+	}
+
+	line, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	rec.lastUsed = time.Now()
+	rec.dirty = true
+	if _, err := rec.buf.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return nil
+}
+
+// redactToken blanks out auth.token in a GSI request body, so recordings
+// can be shared without leaking it.
+func redactToken(body string) json.RawMessage {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(body), &fields); err != nil || fields["auth"] == nil {
+		return json.RawMessage(body)
+	}
+
+	var auth map[string]json.RawMessage
+	if err := json.Unmarshal(fields["auth"], &auth); err != nil || auth["token"] == nil {
+		return json.RawMessage(body)
+	}
+	auth["token"] = json.RawMessage(`"` + REDACTED_TOKEN + `"`)
+
+	redacted, err := json.Marshal(auth)
+	if err != nil {
+		return json.RawMessage(body)
+	}
+	fields["auth"] = redacted
+	if redacted, err = json.Marshal(fields); err != nil {
+		return json.RawMessage(body)
+	}
+	return redacted
+}
+
+// openRecording opens <dir>/<matchID>-<seq>.ndjson.gz, skipping sequence
+// numbers that already exist.
+func (r *recorder) openRecording(matchID string, seq int) (*recording, error) {
+	if matchID == "" {
+		matchID = "unknown"
+	}
+
+	for {
+		path := filepath.Join(r.dir, fmt.Sprintf("%s-%03d.ndjson.gz", matchID, seq))
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if os.IsExist(err) {
+			seq++
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to create recording %s: %w", path, err)
+		}
+
+		counter := &countingWriter{w: file}
+		gz := gzip.NewWriter(counter)
+		return &recording{
+			matchID: matchID,
+			seq:     seq,
+			file:    file,
+			counter: counter,
+			gz:      gz,
+			buf:     bufio.NewWriter(gz),
+		}, nil
+	}
+}
+
+// flush pushes what has been written so far through to the file, so it
+// survives a crash.
+func (rec *recording) flush() {
+	if !rec.dirty {
+		return
+	}
+	if err := rec.buf.Flush(); err != nil {
+		log.Printf("failed to flush recording %s: %v", rec.file.Name(), err)
+		return
+	}
+	if err := rec.gz.Flush(); err != nil {
+		log.Printf("failed to flush recording %s: %v", rec.file.Name(), err)
+		return
+	}
+	rec.dirty = false
+}
+
+func (rec *recording) close() {
+	if err := rec.buf.Flush(); err != nil {
+		log.Printf("failed to flush recording %s: %v", rec.file.Name(), err)
+	}
+	if err := rec.gz.Close(); err != nil {
+		log.Printf("failed to close recording %s: %v", rec.file.Name(), err)
+	}
+	if err := rec.file.Close(); err != nil {
+		log.Printf("failed to close recording %s: %v", rec.file.Name(), err)
+	}
+}
+
+// countingWriter tracks how many compressed bytes reached the file.
+type countingWriter struct {
+	w *os.File
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,129 @@
+package gsi
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ukpabik/CSYou/pkg/event_bus"
+	"github.com/ukpabik/CSYou/pkg/kafka_io"
+	"github.com/ukpabik/CSYou/pkg/shared"
+	"github.com/ukpabik/CSYou/pkg/simulator"
+)
+
+// capturingBus keeps everything published to it.
+type capturingBus struct {
+	mu       sync.Mutex
+	messages []event_bus.Message
+}
+
+func (b *capturingBus) Publish(ctx context.Context, topic string, key, value []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.messages = append(b.messages, event_bus.Message{Topic: topic, Key: key, Value: value})
+	return nil
+}
+
+func (b *capturingBus) Subscribe(topic, group string, handle func(event_bus.Message) error) error {
+	return nil
+}
+
+func (b *capturingBus) Close() error { return nil }
+
+// storedRow is the sorting key an event is stored under, which the event
+// tables keep one row for.
+type storedRow struct {
+	topic   string
+	matchID string
+	steamID string
+	eventID string
+}
+
+// replay injects a recording into fresh sessions, as a new collector would,
+// and returns the rows its events are stored as.
+func replay(t *testing.T, recording [][]byte, tick time.Duration) []storedRow {
+	t.Helper()
+
+	sessions = &sessionStore{sessions: make(map[sessionKey]*Session)}
+	payloads = &payloadGuard{}
+	bus := &capturingBus{}
+	kafka_io.Bus = bus
+
+	receivedAt := time.Unix(1700000000, 0)
+	for _, body := range recording {
+		// cs2gsi's checkers panic on a few partial payloads, which are
+		// skipped the same way live
+		Inject(body, receivedAt)
+		receivedAt = receivedAt.Add(tick)
+	}
+
+	var rows []storedRow
+	for _, message := range bus.messages {
+		var envelope shared.EventEnvelope
+		if err := json.Unmarshal(message.Value, &envelope); err != nil {
+			t.Fatal(err)
+		}
+		var event struct {
+			MatchID string `json:"match_id"`
+			SteamID string `json:"steamid"`
+		}
+		if err := json.Unmarshal(envelope.Payload, &event); err != nil {
+			t.Fatal(err)
+		}
+
+		eventID := envelope.EventID
+		if eventID == "" {
+			t.Fatalf("%s event without an ID: %s", message.Topic, envelope.Payload)
+		}
+		rows = append(rows, storedRow{message.Topic, event.MatchID, event.SteamID, eventID})
+	}
+	return rows
+}
+
+func TestReplayingTwiceStoresNoDuplicates(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	opts := simulator.DefaultOptions()
+	opts.Rounds = 6
+	opts.EventRate = 1
+	opts.AllPlayers = true
+	opts.Seed = 1
+
+	var recording [][]byte
+	if _, err := simulator.Run(context.Background(), opts, 0, func(body []byte) error {
+		recording = append(recording, append([]byte(nil), body...))
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	first := replay(t, recording, opts.Tick)
+	second := replay(t, recording, opts.Tick)
+	if len(first) == 0 {
+		t.Fatal("the recording produced no events")
+	}
+
+	stored := make(map[storedRow]bool)
+	for _, row := range first {
+		stored[row] = true
+	}
+	rows := len(stored)
+	for _, row := range second {
+		if !stored[row] {
+			t.Errorf("replaying again stored a new row: %+v", row)
+		}
+		stored[row] = true
+	}
+	if len(stored) != rows {
+		t.Fatalf("replaying again grew %d rows to %d", rows, len(stored))
+	}
+	if len(second) != len(first) {
+		t.Fatalf("replaying again published %d events, the first replay %d", len(second), len(first))
+	}
+}
@@ -0,0 +1,60 @@
+package gsi
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ukpabik/CSYou/pkg/match_events"
+	"github.com/ukpabik/CSYou/pkg/player_events"
+)
+
+// RECONNECT_TIMEOUT is how long a client can go quiet before its next payload
+// is treated as a reconnect.
+const RECONNECT_TIMEOUT = 30 * time.Second
+
+// Session holds the match tracking state for one GSI client. A client is
+// identified by the SteamID it reports as provider and the auth token it
+// sends, so several players can share one collector.
+type Session struct {
+	mu sync.Mutex
+
+	SteamID string // provider steamid of the client
+	Owner   string // owner of the auth token, if any
+
+	Match     match_events.Lifecycle
+	LastRound int
+	LastSeen  time.Time
+
+	// Kill, death and assist deltas for the current match
+	Tracker *player_events.Tracker
+}
+
+type sessionKey struct {
+	steamID string
+	token   string
+}
+
+type sessionStore struct {
+	mu       sync.Mutex
+	sessions map[sessionKey]*Session
+}
+
+var sessions = &sessionStore{sessions: make(map[sessionKey]*Session)}
+
+// get returns the session for a client, creating it on first contact.
+func (s *sessionStore) get(steamID, token, owner string) *Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := sessionKey{steamID: steamID, token: token}
+	session, ok := s.sessions[key]
+	if !ok {
+		session = &Session{
+			SteamID: steamID,
+			Owner:   owner,
+			Tracker: player_events.NewTracker(),
+		}
+		s.sessions[key] = session
+	}
+	return session
+}
@@ -0,0 +1,49 @@
+package gsi
+
+import (
+	"sort"
+
+	"github.com/LukeyR/CS2-GameStateIntegration/pkg/cs2gsi/structs"
+)
+
+// SPECTATOR_MODE turns every allplayers entry into its own player stream.
+var SPECTATOR_MODE bool
+
+// spectatorViews returns a copy of gsiEvent for each entry in allplayers, with
+// Player swapped for a copy of that entry, so the single-player pipeline can
+// run on it unchanged. Only players in PLAYER_IDS get a view, if it is set.
+// Views are ordered by SteamID.
+func spectatorViews(gsiEvent *structs.GSIEvent, payload *rawPayload) []*structs.GSIEvent {
+	steamIDs := make([]string, 0, len(payload.AllPlayers))
+	for steamID, player := range payload.AllPlayers {
+		if player == nil || (len(PLAYER_IDS) > 0 && !PLAYER_IDS[steamID]) {
+			continue
+		}
+		steamIDs = append(steamIDs, steamID)
+	}
+	sort.Strings(steamIDs)
+
+	views := make([]*structs.GSIEvent, 0, len(steamIDs))
+	for _, steamID := range steamIDs {
+		// payload.AllPlayers is still read for the alive counts
+		player := *payload.AllPlayers[steamID]
+		player.Steamid = steamID
+
+		// allplayers entries omit these when the matching data block is off
+		if player.State.Health == nil {
+			player.State.Health = new(int)
+		}
+		if player.State.Armor == nil {
+			player.State.Armor = new(int)
+		}
+
+		view := *gsiEvent
+		view.Player = &player
+		// Previously/added describe the observed player, not this one
+		view.Previous = nil
+		view.Added = nil
+		views = append(views, &view)
+	}
+
+	return views
+}
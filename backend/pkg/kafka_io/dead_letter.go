@@ -0,0 +1,175 @@
+package kafka_io
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ukpabik/CSYou/pkg/db"
+	"github.com/ukpabik/CSYou/pkg/event_bus"
+	"github.com/ukpabik/CSYou/pkg/shared"
+)
+
+var (
+	RETRY_ATTEMPTS    = 5                      // tries before a message is dead-lettered
+	RETRY_BACKOFF     = 500 * time.Millisecond // first wait, doubled after each failure
+	RETRY_MAX_BACKOFF = 30 * time.Second
+)
+
+// permanentError is a failure retrying won't fix, such as a message that
+// doesn't decode.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+func permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// consume subscribes group to topic and passes each message to handle.
+// Failing messages are retried with backoff and then dead-lettered, and the
+// subscription is restarted if it fails, until the bus is closed. A message
+// is only committed once it has been handled or dead-lettered, so one that
+// was in flight when the process died is delivered again.
+func consume(topic, group, name string, handle func(event_bus.Message) error) {
+	backoff := RETRY_BACKOFF
+	for {
+		log.Printf("Starting %s event consumer loop...", name)
+		err := Bus.Subscribe(topic, group, func(message event_bus.Message) error {
+			log.Printf("Received %s event (key: %s)", name, string(message.Key))
+			if err := handleWithRetry(group, name, message, handle); err != nil {
+				return err
+			}
+
+			// The subscription is healthy again
+			backoff = RETRY_BACKOFF
+			return nil
+		})
+		if err == nil || errors.Is(err, event_bus.ErrClosed) {
+			break
+		}
+
+		log.Printf("Error reading %s event message, restarting in %v: %v", name, backoff, err)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, RETRY_MAX_BACKOFF)
+	}
+	log.Printf("Stopped %s event consumer loop", name)
+}
+
+// handleWithRetry passes message to handle until it succeeds, fails
+// permanently or runs out of attempts, and dead-letters it in the last two
+// cases. It only returns an error if the dead letter couldn't be written, in
+// which case message mustn't be committed.
+func handleWithRetry(group, name string, message event_bus.Message, handle func(event_bus.Message) error) error {
+	backoff := RETRY_BACKOFF
+	attempts := 0
+	var err error
+	for attempts < RETRY_ATTEMPTS {
+		attempts++
+		err = handle(message)
+		if err == nil {
+			return nil
+		}
+		if errors.Is(err, db.ErrNoClient) {
+			skipClickHouse(name)
+			return nil
+		}
+		if errors.As(err, new(permanentError)) || attempts == RETRY_ATTEMPTS {
+			break
+		}
+
+		log.Printf("failed to process %s event (attempt %d/%d), retrying in %v: %v", name, attempts, RETRY_ATTEMPTS, backoff, err)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, RETRY_MAX_BACKOFF)
+	}
+
+	log.Printf("giving up on %s event after %d attempt(s): %v", name, attempts, err)
+	return deadLetter(group, message, err, attempts)
+}
+
+// skipped holds the consumers that have logged storing without ClickHouse.
+var skipped sync.Map
+
+// skipClickHouse notes that an event was only stored in Redis. ClickHouse is
+// only connected at startup, so without it every event would fail the same
+// way; retrying or dead-lettering them would only fill the log and the
+// dead-letter topic. This is logged once per consumer.
+func skipClickHouse(name string) {
+	if _, logged := skipped.LoadOrStore(name, true); !logged {
+		log.Printf("ClickHouse isn't connected, %s events are only stored in Redis", name)
+	}
+}
+
+// deadLetter publishes message to the dead-letter topic along with why it
+// failed. Dead letters that can't be stored are logged in full instead, as
+// there is nowhere else to put them.
+func deadLetter(group string, message event_bus.Message, err error, attempts int) error {
+	dl := &shared.DeadLetter{
+		ID:       uuid.New().String(),
+		Topic:    message.Topic,
+		Group:    group,
+		Key:      string(message.Key),
+		Value:    string(message.Value),
+		Offset:   message.Offset,
+		Error:    err.Error(),
+		Attempts: attempts,
+		FailedAt: time.Now().UnixMilli(),
+	}
+
+	if message.Topic == DEAD_LETTER_TOPIC {
+		log.Printf("dropping dead letter that could not be stored: %s", message.Value)
+		return nil
+	}
+	if err := writeEvent(DEAD_LETTER_TOPIC, dl.ID, dl, dl.Key); err != nil {
+		return fmt.Errorf("failed to write dead letter for %s message: %w", message.Topic, err)
+	}
+	return nil
+}
+
+// ReadDeadLetterLoop reads from dead_letters topic
+func ReadDeadLetterLoop() {
+	readEventLoop(DEAD_LETTER_TOPIC, "cs2-dead-letter-processor", "dead letter", func(dl *shared.DeadLetter) error {
+		if err := db.InsertDeadLetter(dl); err != nil {
+			return fmt.Errorf("unable to insert dead letter into clickhouse: %w", err)
+		}
+
+		log.Printf("Stored dead letter %s from %s: %s", dl.ID, dl.Topic, dl.Error)
+		return nil
+	})
+}
+
+// RedriveDeadLetters publishes the dead letters not yet re-driven back to
+// the topics they came from and marks them re-driven. topic and ids narrow
+// them down if set. It returns how many were re-driven.
+func RedriveDeadLetters(topic string, ids []string) (int, error) {
+	deadLetters, err := db.GetDeadLetters(topic, ids, false)
+	if err != nil {
+		return 0, err
+	}
+
+	var redriven []string
+	for _, dl := range deadLetters {
+		if err := Bus.Publish(context.Background(), dl.Topic, []byte(dl.Key), []byte(dl.Value)); err != nil {
+			// Mark the ones that did go, so they aren't sent twice
+			if markErr := db.MarkDeadLettersRedriven(redriven); markErr != nil {
+				log.Printf("failed to mark dead letters re-driven: %v", markErr)
+			}
+			return len(redriven), fmt.Errorf("failed to re-drive dead letter %s: %w", dl.ID, err)
+		}
+		redriven = append(redriven, dl.ID)
+	}
+
+	if err := db.MarkDeadLettersRedriven(redriven); err != nil {
+		return len(redriven), fmt.Errorf("re-drove %d dead letter(s) but failed to mark them: %w", len(redriven), err)
+	}
+
+	log.Printf("Re-drove %d dead letter(s)", len(redriven))
+	return len(redriven), nil
+}
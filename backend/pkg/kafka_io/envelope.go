@@ -0,0 +1,159 @@
+package kafka_io
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ukpabik/CSYou/pkg/shared"
+)
+
+// eventSchema is the event type a topic carries and the version its struct
+// is written at.
+type eventSchema struct {
+	Type    string
+	Version int
+}
+
+// EVENT_SCHEMAS are the current schemas, by topic. Version 1 is the bare
+// JSON written before envelopes. Bump a version whenever its struct changes
+// in a way older readers would get wrong, and add an upcaster from the old
+// version.
+var EVENT_SCHEMAS = map[string]eventSchema{
+	PLAYER_EVENT_TOPIC:  {shared.EVENT_PLAYER, 2},
+	KILL_EVENT_TOPIC:    {shared.EVENT_KILL, 2},
+	DEATH_EVENT_TOPIC:   {shared.EVENT_DEATH, 2},
+	ASSIST_EVENT_TOPIC:  {shared.EVENT_ASSIST, 2},
+	MATCH_EVENT_TOPIC:   {shared.EVENT_MATCH, 2},
+	ROUND_SUMMARY_TOPIC: {shared.EVENT_ROUND_SUMMARY, 2},
+	BOMB_EVENT_TOPIC:    {shared.EVENT_BOMB, 2},
+	UTILITY_EVENT_TOPIC: {shared.EVENT_UTILITY, 2},
+	DAMAGE_EVENT_TOPIC:  {shared.EVENT_DAMAGE, 2},
+	CLUTCH_EVENT_TOPIC:  {shared.EVENT_CLUTCH, 2},
+	MULTI_KILL_TOPIC:    {shared.EVENT_MULTI_KILL, 2},
+	BADGE_EVENT_TOPIC:   {shared.EVENT_BADGE, 2},
+	DUEL_EVENT_TOPIC:    {shared.EVENT_DUEL, 2},
+	DEAD_LETTER_TOPIC:   {shared.EVENT_DEAD_LETTER, 1},
+}
+
+// upcaster rewrites an envelope's payload from its version to the next.
+type upcaster func(envelope *shared.EventEnvelope) error
+
+// upcasters are keyed by event type, then by the version they upgrade from.
+var upcasters = map[string]map[int]upcaster{
+	// Version 2 added event IDs
+	shared.EVENT_PLAYER:        {1: addEventID},
+	shared.EVENT_KILL:          {1: addEventID},
+	shared.EVENT_DEATH:         {1: addEventID},
+	shared.EVENT_ASSIST:        {1: addEventID},
+	shared.EVENT_MATCH:         {1: addEventID},
+	shared.EVENT_ROUND_SUMMARY: {1: addEventID},
+	shared.EVENT_BOMB:          {1: addEventID},
+	shared.EVENT_UTILITY:       {1: addEventID},
+	shared.EVENT_DAMAGE:        {1: addEventID},
+	shared.EVENT_CLUTCH:        {1: addEventID},
+	shared.EVENT_MULTI_KILL:    {1: addEventID},
+	shared.EVENT_BADGE:         {1: addEventID},
+	shared.EVENT_DUEL:          {1: addEventID},
+}
+
+// sealEnvelope wraps event in an envelope for topic.
+func sealEnvelope(topic, eventID string, event any, key string) ([]byte, error) {
+	schema, ok := EVENT_SCHEMAS[topic]
+	if !ok {
+		return nil, fmt.Errorf("no schema for topic %s", topic)
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(&shared.EventEnvelope{
+		Type:       schema.Type,
+		Version:    schema.Version,
+		EventID:    eventID,
+		ProducedAt: time.Now().UnixMilli(),
+		SteamID:    key,
+		Payload:    payload,
+	})
+}
+
+// openEnvelope unwraps a message from topic and upcasts its payload to the
+// current version. Messages from before envelopes are taken as version 1.
+func openEnvelope(topic string, key, value []byte) (json.RawMessage, error) {
+	schema, ok := EVENT_SCHEMAS[topic]
+	if !ok {
+		return nil, fmt.Errorf("no schema for topic %s", topic)
+	}
+
+	var envelope shared.EventEnvelope
+	if err := json.Unmarshal(value, &envelope); err != nil {
+		return nil, err
+	}
+	if envelope.Payload == nil {
+		envelope = shared.EventEnvelope{
+			Type:    schema.Type,
+			Version: 1,
+			SteamID: string(key),
+			Payload: value,
+		}
+	}
+
+	if envelope.Type != schema.Type {
+		return nil, fmt.Errorf("%s event on the %s topic", envelope.Type, topic)
+	}
+	if envelope.Version > schema.Version {
+		return nil, fmt.Errorf("%s event is version %d, this build reads up to %d", envelope.Type, envelope.Version, schema.Version)
+	}
+
+	for envelope.Version < schema.Version {
+		upcast, ok := upcasters[envelope.Type][envelope.Version]
+		if !ok {
+			return nil, fmt.Errorf("no upcaster for %s event version %d", envelope.Type, envelope.Version)
+		}
+		if err := upcast(&envelope); err != nil {
+			return nil, fmt.Errorf("failed to upcast %s event from version %d: %w", envelope.Type, envelope.Version, err)
+		}
+		envelope.Version++
+	}
+
+	return envelope.Payload, nil
+}
+
+// addEventID gives an event written without an event ID one. How far into
+// the match it was is unknown, so the ID is derived from the payload, which
+// is the same each time the message is delivered.
+func addEventID(envelope *shared.EventEnvelope) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(envelope.Payload, &fields); err != nil {
+		return err
+	}
+
+	var event struct {
+		MatchID string `json:"match_id"`
+		Round   int    `json:"round"`
+		SteamID string `json:"steamid"`
+		EventID string `json:"event_id"`
+	}
+	if err := json.Unmarshal(envelope.Payload, &event); err != nil {
+		return err
+	}
+
+	if event.EventID == "" {
+		event.EventID = shared.EventID(envelope.Type, event.MatchID, event.Round, event.SteamID, string(envelope.Payload))
+		id, err := json.Marshal(event.EventID)
+		if err != nil {
+			return err
+		}
+		fields["event_id"] = id
+
+		payload, err := json.Marshal(fields)
+		if err != nil {
+			return err
+		}
+		envelope.Payload = payload
+	}
+	envelope.EventID = event.EventID
+	return nil
+}
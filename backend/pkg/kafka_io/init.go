@@ -4,67 +4,50 @@ import (
 	"fmt"
 	"log"
 
-	"github.com/segmentio/kafka-go"
+	"github.com/ukpabik/CSYou/pkg/config"
+	"github.com/ukpabik/CSYou/pkg/event_bus"
 )
 
 const (
-	PLAYER_EVENT_TOPIC = "player_events"
-	KILL_EVENT_TOPIC   = "kill_events"
+	PLAYER_EVENT_TOPIC  = "player_events"
+	KILL_EVENT_TOPIC    = "kill_events"
+	DEATH_EVENT_TOPIC   = "death_events"
+	ASSIST_EVENT_TOPIC  = "assist_events"
+	MATCH_EVENT_TOPIC   = "match_events"
+	ROUND_SUMMARY_TOPIC = "round_summaries"
+	BOMB_EVENT_TOPIC    = "bomb_events"
+	UTILITY_EVENT_TOPIC = "utility_events"
+	DAMAGE_EVENT_TOPIC  = "damage_events"
+	CLUTCH_EVENT_TOPIC  = "clutch_events"
+	MULTI_KILL_TOPIC    = "multi_kill_events"
+	BADGE_EVENT_TOPIC   = "badge_events"
+	DUEL_EVENT_TOPIC    = "duel_events"
+	DEAD_LETTER_TOPIC   = "dead_letters"
 )
 
-var (
-	PlayerEventWriter *kafka.Writer
-	KillEventWriter   *kafka.Writer
-	PlayerEventReader *kafka.Reader
-	KillEventReader   *kafka.Reader
-)
-
-func InitializeReaderAndWriter(addr string, port int) {
+// Bus carries every topic, on Kafka or in process as configured.
+var Bus event_bus.EventBus
 
-	location := fmt.Sprintf("%s:%d", addr, port)
-	// Writers
-	PlayerEventWriter = &kafka.Writer{
-		Addr:     kafka.TCP(location),
-		Topic:    PLAYER_EVENT_TOPIC,
-		Balancer: &kafka.LeastBytes{},
+// InitializeEventBus sets up the bus chosen by cfg. The Kafka backend uses
+// the broker at addr:port.
+func InitializeEventBus(cfg config.EventBusConfig, addr string, port int) error {
+	bus, err := event_bus.New(cfg, fmt.Sprintf("%s:%d", addr, port))
+	if err != nil {
+		return err
 	}
 
-	KillEventWriter = &kafka.Writer{
-		Addr:     kafka.TCP(location),
-		Topic:    KILL_EVENT_TOPIC,
-		Balancer: &kafka.LeastBytes{},
+	Bus = bus
+	if cfg.Backend == event_bus.BACKEND_MEMORY {
+		log.Println("Using the in-process event bus")
 	}
-
-	// Readers
-	PlayerEventReader = kafka.NewReader(kafka.ReaderConfig{
-		Brokers: []string{location},
-		Topic:   PLAYER_EVENT_TOPIC,
-		GroupID: "cs2-player-processor",
-	})
-
-	KillEventReader = kafka.NewReader(kafka.ReaderConfig{
-		Brokers: []string{location},
-		Topic:   KILL_EVENT_TOPIC,
-		GroupID: "cs2-kill-processor",
-	})
+	return nil
 }
 
-func CloseReaderAndWriters() {
-	writers := []*kafka.Writer{PlayerEventWriter, KillEventWriter}
-	for _, writer := range writers {
-		if writer != nil {
-			if err := writer.Close(); err != nil {
-				log.Printf("failed to close writer: %v", err)
-			}
-		}
+func CloseEventBus() {
+	if Bus == nil {
+		return
 	}
-
-	readers := []*kafka.Reader{PlayerEventReader, KillEventReader}
-	for _, reader := range readers {
-		if reader != nil {
-			if err := reader.Close(); err != nil {
-				log.Printf("failed to close reader: %v", err)
-			}
-		}
+	if err := Bus.Close(); err != nil {
+		log.Printf("failed to close event bus: %v", err)
 	}
 }
@@ -3,97 +3,282 @@ package kafka_io
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 
-	"github.com/segmentio/kafka-go"
 	"github.com/ukpabik/CSYou/pkg/db"
+	"github.com/ukpabik/CSYou/pkg/event_bus"
 	"github.com/ukpabik/CSYou/pkg/redis"
 	"github.com/ukpabik/CSYou/pkg/shared"
 )
 
-// WritePlayerEvent writes player event to player_events topic
-func WritePlayerEvent(event *shared.RedisPlayerEvent, key string) error {
-	eventBytes, err := json.Marshal(event)
+// writeEvent serializes event in an envelope and publishes it to topic.
+// eventID is the event's ID, "" if it has none.
+func writeEvent(topic, eventID string, event any, key string) error {
+	eventBytes, err := sealEnvelope(topic, eventID, event, key)
 	if err != nil {
 		return err
 	}
 
-	return PlayerEventWriter.WriteMessages(context.Background(),
-		kafka.Message{
-			Key:   []byte(key),
-			Value: eventBytes,
-		},
-	)
+	return Bus.Publish(context.Background(), topic, []byte(key), eventBytes)
+}
+
+// readEventLoop consumes topic as group, upcasting each message to the
+// current version and decoding it into a T for handle. Failures are retried
+// and then dead-lettered, see consume.
+func readEventLoop[T any](topic, group, name string, handle func(*T) error) {
+	consume(topic, group, name, func(message event_bus.Message) error {
+		payload, err := openEnvelope(topic, message.Key, message.Value)
+		if err != nil {
+			return permanent(fmt.Errorf("failed to open %s event: %w", name, err))
+		}
+
+		var event T
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return permanent(fmt.Errorf("failed to unmarshal %s event: %w", name, err))
+		}
+
+		return handle(&event)
+	})
+}
+
+// WritePlayerEvent writes player event to player_events topic
+func WritePlayerEvent(event *shared.RedisPlayerEvent, key string) error {
+	return writeEvent(PLAYER_EVENT_TOPIC, event.EventID, event, key)
 }
 
 // WriteKillEvent writes kill event to kill_events topic
 func WriteKillEvent(event *shared.RedisKillEvent, key string) error {
-	eventBytes, err := json.Marshal(event)
-	if err != nil {
-		return err
-	}
+	return writeEvent(KILL_EVENT_TOPIC, event.EventID, event, key)
+}
 
-	return KillEventWriter.WriteMessages(context.Background(),
-		kafka.Message{
-			Key:   []byte(key),
-			Value: eventBytes,
-		},
-	)
+// WriteDeathEvent writes death event to death_events topic
+func WriteDeathEvent(event *shared.RedisDeathEvent, key string) error {
+	return writeEvent(DEATH_EVENT_TOPIC, event.EventID, event, key)
 }
 
-// ReadPlayerEventLoop reads from player_events topic
-func ReadPlayerEventLoop() {
-	log.Println("Starting Kafka player event consumer loop...")
-	for {
-		message, err := PlayerEventReader.ReadMessage(context.Background())
-		if err != nil {
-			log.Printf("Error reading kafka player event message: %v", err)
-			break
-		}
+// WriteAssistEvent writes assist event to assist_events topic
+func WriteAssistEvent(event *shared.RedisAssistEvent, key string) error {
+	return writeEvent(ASSIST_EVENT_TOPIC, event.EventID, event, key)
+}
+
+// WriteMatchEvent writes match lifecycle event to match_events topic
+func WriteMatchEvent(event *shared.MatchEvent, key string) error {
+	return writeEvent(MATCH_EVENT_TOPIC, event.EventID, event, key)
+}
+
+// WriteRoundSummary writes round summary to round_summaries topic
+func WriteRoundSummary(summary *shared.RoundSummary, key string) error {
+	return writeEvent(ROUND_SUMMARY_TOPIC, summary.EventID, summary, key)
+}
+
+// WriteBombEvent writes bomb event to bomb_events topic
+func WriteBombEvent(event *shared.BombEvent, key string) error {
+	return writeEvent(BOMB_EVENT_TOPIC, event.EventID, event, key)
+}
+
+// WriteUtilityEvent writes utility event to utility_events topic
+func WriteUtilityEvent(event *shared.UtilityEvent, key string) error {
+	return writeEvent(UTILITY_EVENT_TOPIC, event.EventID, event, key)
+}
+
+// WriteDamageEvent writes damage event to damage_events topic
+func WriteDamageEvent(event *shared.DamageEvent, key string) error {
+	return writeEvent(DAMAGE_EVENT_TOPIC, event.EventID, event, key)
+}
 
-		log.Printf("Received player event from Kafka (key: %s)", string(message.Key))
+// WriteClutchEvent writes clutch event to clutch_events topic
+func WriteClutchEvent(event *shared.ClutchEvent, key string) error {
+	return writeEvent(CLUTCH_EVENT_TOPIC, event.EventID, event, key)
+}
+
+// WriteMultiKillEvent writes multi-kill event to multi_kill_events topic
+func WriteMultiKillEvent(event *shared.MultiKillEvent, key string) error {
+	return writeEvent(MULTI_KILL_TOPIC, event.EventID, event, key)
+}
 
-		var playerEvent shared.RedisPlayerEvent
-		if err := json.Unmarshal(message.Value, &playerEvent); err != nil {
-			log.Printf("failed to unmarshal player event: %v", err)
-			continue
+// WriteBadgeEvent writes badge event to badge_events topic
+func WriteBadgeEvent(event *shared.BadgeEvent, key string) error {
+	return writeEvent(BADGE_EVENT_TOPIC, event.EventID, event, key)
+}
+
+// WriteDuelEvent writes duel event to duel_events topic
+func WriteDuelEvent(event *shared.DuelEvent, key string) error {
+	return writeEvent(DUEL_EVENT_TOPIC, event.EventID, event, key)
+}
+
+// ReadPlayerEventLoop reads from player_events topic
+func ReadPlayerEventLoop() {
+	readEventLoop(PLAYER_EVENT_TOPIC, "cs2-player-processor", "player", func(playerEvent *shared.RedisPlayerEvent) error {
+		if err := redis.HandlePlayerEvent(playerEvent); err != nil {
+			return fmt.Errorf("unable to cache player event in redis: %w", err)
 		}
-		redis.HandlePlayerEvent(&playerEvent)
 
-		if err := db.InsertPlayerEvent(&playerEvent); err != nil {
-			log.Printf("unable to insert player event into clickhouse: %v", err)
+		if err := db.InsertPlayerEvent(playerEvent); err != nil {
+			return fmt.Errorf("unable to insert player event into clickhouse: %w", err)
 		}
 
 		log.Printf("Processing player event for match %s, player %s", playerEvent.MatchID, playerEvent.SteamID)
-	}
-	log.Println("Kafka player event consumer loop ended")
+		return nil
+	})
 }
 
 // ReadKillEventLoop reads from kill_events topic
 func ReadKillEventLoop() {
-	log.Println("Starting Kafka kill event consumer loop...")
-	for {
-		message, err := KillEventReader.ReadMessage(context.Background())
-		if err != nil {
-			log.Printf("Error reading kafka kill event message: %v", err)
-			break
+	readEventLoop(KILL_EVENT_TOPIC, "cs2-kill-processor", "kill", func(killEvent *shared.RedisKillEvent) error {
+		if err := redis.HandleKillEvent(killEvent); err != nil {
+			return fmt.Errorf("unable to cache kill event in redis: %w", err)
+		}
+
+		if err := db.InsertKillEvent(killEvent); err != nil {
+			return fmt.Errorf("unable to insert kill event into clickhouse: %w", err)
+		}
+
+		log.Printf("Processing kill event for match %s, player %s with %s", killEvent.MatchID, killEvent.SteamID, killEvent.ActiveGun.Name)
+		return nil
+	})
+}
+
+// ReadDeathEventLoop reads from death_events topic
+func ReadDeathEventLoop() {
+	readEventLoop(DEATH_EVENT_TOPIC, "cs2-death-processor", "death", func(deathEvent *shared.RedisDeathEvent) error {
+		if err := redis.HandleDeathEvent(deathEvent); err != nil {
+			return fmt.Errorf("unable to cache death event in redis: %w", err)
+		}
+
+		if err := db.InsertDeathEvent(deathEvent); err != nil {
+			return fmt.Errorf("unable to insert death event into clickhouse: %w", err)
 		}
 
-		log.Printf("Received kill event from Kafka (key: %s)", string(message.Key))
+		log.Printf("Processing death event for match %s, player %s holding %s", deathEvent.MatchID, deathEvent.SteamID, deathEvent.WeaponName)
+		return nil
+	})
+}
+
+// ReadAssistEventLoop reads from assist_events topic
+func ReadAssistEventLoop() {
+	readEventLoop(ASSIST_EVENT_TOPIC, "cs2-assist-processor", "assist", func(assistEvent *shared.RedisAssistEvent) error {
+		if err := redis.HandleAssistEvent(assistEvent); err != nil {
+			return fmt.Errorf("unable to cache assist event in redis: %w", err)
+		}
 
-		var killEvent shared.RedisKillEvent
-		if err := json.Unmarshal(message.Value, &killEvent); err != nil {
-			log.Printf("failed to unmarshal kill event: %v", err)
-			continue
+		if err := db.InsertAssistEvent(assistEvent); err != nil {
+			return fmt.Errorf("unable to insert assist event into clickhouse: %w", err)
 		}
 
-		redis.HandleKillEvent(&killEvent)
+		log.Printf("Processing assist event for match %s, player %s (flash assist: %v)", assistEvent.MatchID, assistEvent.SteamID, assistEvent.FlashAssist)
+		return nil
+	})
+}
 
-		if err := db.InsertKillEvent(&killEvent); err != nil {
-			log.Printf("unable to insert player event into clickhouse: %v", err)
+// ReadMatchEventLoop reads from match_events topic
+func ReadMatchEventLoop() {
+	readEventLoop(MATCH_EVENT_TOPIC, "cs2-match-processor", "match", func(matchEvent *shared.MatchEvent) error {
+		if err := db.InsertMatchEvent(matchEvent); err != nil {
+			return fmt.Errorf("unable to insert match event into clickhouse: %w", err)
 		}
 
-		log.Printf("Processing kill event for match %s, player %s with %s", killEvent.MatchID, killEvent.SteamID, killEvent.ActiveGun.Name)
-	}
-	log.Println("Kafka kill event consumer loop ended")
+		log.Printf("Processing %s event for match %s, player %s on %s", matchEvent.Type, matchEvent.MatchID, matchEvent.SteamID, matchEvent.Map)
+		return nil
+	})
+}
+
+// ReadRoundSummaryLoop reads from round_summaries topic
+func ReadRoundSummaryLoop() {
+	readEventLoop(ROUND_SUMMARY_TOPIC, "cs2-round-processor", "round summary", func(summary *shared.RoundSummary) error {
+		if err := redis.HandleRoundSummary(summary); err != nil {
+			return fmt.Errorf("unable to cache round summary in redis: %w", err)
+		}
+
+		if err := db.InsertRoundSummary(summary); err != nil {
+			return fmt.Errorf("unable to insert round summary into clickhouse: %w", err)
+		}
+
+		log.Printf("Processing round %d summary for match %s, player %s", summary.Round, summary.MatchID, summary.SteamID)
+		return nil
+	})
+}
+
+// ReadBombEventLoop reads from bomb_events topic
+func ReadBombEventLoop() {
+	readEventLoop(BOMB_EVENT_TOPIC, "cs2-bomb-processor", "bomb", func(bombEvent *shared.BombEvent) error {
+		if err := db.InsertBombEvent(bombEvent); err != nil {
+			return fmt.Errorf("unable to insert bomb event into clickhouse: %w", err)
+		}
+
+		log.Printf("Processing bomb %s event for match %s, player %s", bombEvent.Action, bombEvent.MatchID, bombEvent.SteamID)
+		return nil
+	})
+}
+
+// ReadUtilityEventLoop reads from utility_events topic
+func ReadUtilityEventLoop() {
+	readEventLoop(UTILITY_EVENT_TOPIC, "cs2-utility-processor", "utility", func(utilityEvent *shared.UtilityEvent) error {
+		if err := db.InsertUtilityEvent(utilityEvent); err != nil {
+			return fmt.Errorf("unable to insert utility event into clickhouse: %w", err)
+		}
+
+		log.Printf("Processing utility event for match %s, player %s %s %s", utilityEvent.MatchID, utilityEvent.SteamID, utilityEvent.Action, utilityEvent.Grenade)
+		return nil
+	})
+}
+
+// ReadDamageEventLoop reads from damage_events topic
+func ReadDamageEventLoop() {
+	readEventLoop(DAMAGE_EVENT_TOPIC, "cs2-damage-processor", "damage", func(damageEvent *shared.DamageEvent) error {
+		if err := db.InsertDamageEvent(damageEvent); err != nil {
+			return fmt.Errorf("unable to insert damage event into clickhouse: %w", err)
+		}
+
+		log.Printf("Processing damage event for match %s, player %s took %d", damageEvent.MatchID, damageEvent.SteamID, damageEvent.HealthDamage)
+		return nil
+	})
+}
+
+// ReadClutchEventLoop reads from clutch_events topic
+func ReadClutchEventLoop() {
+	readEventLoop(CLUTCH_EVENT_TOPIC, "cs2-clutch-processor", "clutch", func(clutchEvent *shared.ClutchEvent) error {
+		if err := db.InsertClutchEvent(clutchEvent); err != nil {
+			return fmt.Errorf("unable to insert clutch event into clickhouse: %w", err)
+		}
+
+		log.Printf("Processing clutch %s event for match %s, player %s 1v%d", clutchEvent.Action, clutchEvent.MatchID, clutchEvent.SteamID, clutchEvent.Vs)
+		return nil
+	})
+}
+
+// ReadMultiKillEventLoop reads from multi_kill_events topic
+func ReadMultiKillEventLoop() {
+	readEventLoop(MULTI_KILL_TOPIC, "cs2-multi-kill-processor", "multi-kill", func(multiKill *shared.MultiKillEvent) error {
+		if err := db.InsertMultiKillEvent(multiKill); err != nil {
+			return fmt.Errorf("unable to insert multi-kill event into clickhouse: %w", err)
+		}
+
+		log.Printf("Processing %s event for match %s, player %s", multiKill.Label, multiKill.MatchID, multiKill.SteamID)
+		return nil
+	})
+}
+
+// ReadBadgeEventLoop reads from badge_events topic
+func ReadBadgeEventLoop() {
+	readEventLoop(BADGE_EVENT_TOPIC, "cs2-badge-processor", "badge", func(badgeEvent *shared.BadgeEvent) error {
+		if err := db.InsertBadgeEvent(badgeEvent); err != nil {
+			return fmt.Errorf("unable to insert badge event into clickhouse: %w", err)
+		}
+
+		log.Printf("Processing %s badge for match %s, player %s", badgeEvent.Badge, badgeEvent.MatchID, badgeEvent.SteamID)
+		return nil
+	})
+}
+
+// ReadDuelEventLoop reads from duel_events topic
+func ReadDuelEventLoop() {
+	readEventLoop(DUEL_EVENT_TOPIC, "cs2-duel-processor", "duel", func(duelEvent *shared.DuelEvent) error {
+		if err := db.InsertDuelEvent(duelEvent); err != nil {
+			return fmt.Errorf("unable to insert duel event into clickhouse: %w", err)
+		}
+
+		log.Printf("Processing duel %s event for match %s, player %s", duelEvent.Kind, duelEvent.MatchID, duelEvent.SteamID)
+		return nil
+	})
 }
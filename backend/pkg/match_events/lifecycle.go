@@ -0,0 +1,227 @@
+package match_events
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/LukeyR/CS2-GameStateIntegration/pkg/cs2gsi/structs"
+	"github.com/google/uuid"
+	"github.com/ukpabik/CSYou/pkg/shared"
+)
+
+type MatchState string
+
+const (
+	STATE_WARMUP   MatchState = "warmup"
+	STATE_LIVE     MatchState = "live"
+	STATE_HALFTIME MatchState = "halftime"
+	STATE_OVERTIME MatchState = "overtime"
+	STATE_GAMEOVER MatchState = "gameover"
+)
+
+const (
+	RESULT_WIN       = "win"
+	RESULT_LOSS      = "loss"
+	RESULT_TIE       = "tie"
+	RESULT_ABANDONED = "abandoned"
+)
+
+// Lifecycle follows one GSI client through its matches. A match runs from
+// the first payload on a map until gameover, and a new one starts when the
+// map changes, the game leaves gameover for a rematch, or the round counter
+// goes back (a restart).
+type Lifecycle struct {
+	State   MatchState
+	MatchID string
+	Map     string
+	Mode    string
+
+	startedAt time.Time
+	round     int
+	scoreCT   int
+	scoreT    int
+	team      string
+	overtime  bool
+}
+
+// Update advances the lifecycle with a payload from the client steamID and
+// returns any MatchStarted/MatchEnded events it caused.
+func (l *Lifecycle) Update(event *structs.GSIEvent, steamID string, now time.Time) []*shared.MatchEvent {
+	csMap := event.CSMap
+	state := stateOf(event)
+
+	var matchEvents []*shared.MatchEvent
+	started := false
+	switch {
+	case l.MatchID == "":
+		l.start(event, steamID, state, now)
+		// Picking up at the scoreboard, nothing left to track
+		started = state != STATE_GAMEOVER
+
+	case csMap.Name != l.Map,
+		l.State == STATE_GAMEOVER && state != STATE_GAMEOVER,
+		state != STATE_GAMEOVER && csMap.Round < l.round:
+		// Left without reaching gameover
+		if l.State != STATE_GAMEOVER {
+			matchEvents = append(matchEvents, l.endedEvent(steamID, RESULT_ABANDONED, now))
+		}
+		l.start(event, steamID, state, now)
+		started = true
+	}
+
+	l.round = csMap.Round
+	if csMap.TeamCt != nil {
+		l.scoreCT = csMap.TeamCt.Score
+	}
+	if csMap.TeamT != nil {
+		l.scoreT = csMap.TeamT.Score
+	}
+	if event.Player != nil && event.Player.Steamid == steamID {
+		l.team = event.Player.Team
+	}
+	if started {
+		matchEvents = append(matchEvents, l.startedEvent(steamID, csMap.Round > 0))
+	}
+
+	// Once in overtime, round ends don't drop back to live
+	if state == STATE_LIVE && l.overtime {
+		state = STATE_OVERTIME
+	}
+	if state == l.State {
+		return matchEvents
+	}
+	l.State = state
+	if state == STATE_OVERTIME {
+		l.overtime = true
+	}
+	if state == STATE_GAMEOVER {
+		matchEvents = append(matchEvents, l.endedEvent(steamID, l.result(), now))
+	}
+
+	return matchEvents
+}
+
+// start resets the lifecycle for a new match.
+func (l *Lifecycle) start(event *structs.GSIEvent, steamID string, state MatchState, now time.Time) {
+	*l = Lifecycle{
+		State:     state,
+		MatchID:   matchID(event, steamID),
+		Map:       event.CSMap.Name,
+		Mode:      event.CSMap.Mode,
+		startedAt: now,
+		overtime:  state == STATE_OVERTIME,
+	}
+}
+
+// MATCH_ID_NAMESPACE is the UUID namespace match IDs are derived in.
+var MATCH_ID_NAMESPACE = uuid.MustParse("5b0c7a44-3f1e-4c8e-9a53-2d6f0e1b7c92")
+
+// matchID derives the ID of a match from the client, the map and the time
+// the client stamped on its first payload, so replaying a recording gives
+// its events the same IDs as when it was live.
+func matchID(event *structs.GSIEvent, steamID string) string {
+	var timestamp int
+	if event.Provider != nil {
+		timestamp = event.Provider.Timestamp
+	}
+	return uuid.NewSHA1(MATCH_ID_NAMESPACE, fmt.Appendf(nil, "%s|%s|%d", steamID, event.CSMap.Name, timestamp)).String()
+}
+
+func (l *Lifecycle) startedEvent(steamID string, joinedInProgress bool) *shared.MatchEvent {
+	return &shared.MatchEvent{
+		Type:             shared.MATCH_STARTED,
+		MatchID:          l.MatchID,
+		EventID:          shared.EventID(shared.EVENT_MATCH, l.MatchID, 0, steamID, shared.MATCH_STARTED),
+		Map:              l.Map,
+		Mode:             l.Mode,
+		SteamID:          steamID,
+		Team:             l.team,
+		State:            string(l.State),
+		JoinedInProgress: joinedInProgress,
+		StartedAt:        l.startedAt.Unix(),
+	}
+}
+
+func (l *Lifecycle) endedEvent(steamID, result string, now time.Time) *shared.MatchEvent {
+	winner := ""
+	switch {
+	case l.scoreCT > l.scoreT:
+		winner = "CT"
+	case l.scoreT > l.scoreCT:
+		winner = "T"
+	}
+
+	return &shared.MatchEvent{
+		Type:      shared.MATCH_ENDED,
+		MatchID:   l.MatchID,
+		EventID:   shared.EventID(shared.EVENT_MATCH, l.MatchID, 0, steamID, shared.MATCH_ENDED),
+		Map:       l.Map,
+		Mode:      l.Mode,
+		SteamID:   steamID,
+		Team:      l.team,
+		State:     string(l.State),
+		ScoreCT:   l.scoreCT,
+		ScoreT:    l.scoreT,
+		Rounds:    l.scoreCT + l.scoreT,
+		Winner:    winner,
+		Result:    result,
+		Overtime:  l.overtime,
+		StartedAt: l.startedAt.Unix(),
+		EndedAt:   now.Unix(),
+		Duration:  int64(now.Sub(l.startedAt).Seconds()),
+	}
+}
+
+// result is the final result from the client's side, or "" when spectating.
+func (l *Lifecycle) result() string {
+	ours, theirs := l.scoreCT, l.scoreT
+	switch l.team {
+	case "CT":
+	case "T":
+		ours, theirs = theirs, ours
+	default:
+		return ""
+	}
+
+	switch {
+	case ours > theirs:
+		return RESULT_WIN
+	case ours < theirs:
+		return RESULT_LOSS
+	}
+	return RESULT_TIE
+}
+
+// stateOf maps the GSI map phase onto the lifecycle. GSI has no overtime
+// phase, so it is inferred from the round count once a round past regulation
+// starts. The count already passes regulation while the last round is over.
+func stateOf(event *structs.GSIEvent) MatchState {
+	csMap := event.CSMap
+	switch csMap.Phase {
+	case "warmup":
+		return STATE_WARMUP
+	case "intermission":
+		return STATE_HALFTIME
+	case "gameover":
+		return STATE_GAMEOVER
+	}
+
+	regulation := RegulationRounds(csMap.Mode)
+	roundOver := event.Round != nil && event.Round.Phase == "over"
+	if regulation > 0 && csMap.Round >= regulation && !roundOver {
+		return STATE_OVERTIME
+	}
+	return STATE_LIVE
+}
+
+// RegulationRounds is the number of rounds before overtime, or 0 for modes
+// without overtime.
+func RegulationRounds(mode string) int {
+	switch mode {
+	case "competitive", "premier":
+		return 24
+	case "scrimcomp2v2", "wingman":
+		return 16
+	}
+	return 0
+}
@@ -0,0 +1,170 @@
+package match_events
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LukeyR/CS2-GameStateIntegration/pkg/cs2gsi/structs"
+)
+
+const steamID = "76561198000000001"
+
+// step is one payload of a match, as the fields the lifecycle reads.
+type step struct {
+	mapName    string
+	phase      string
+	round      int
+	roundPhase string
+	scoreCT    int
+	scoreT     int
+}
+
+// matchPayload is a competitive payload for a CT player, stamped at
+// timestamp.
+func matchPayload(t *testing.T, s step, timestamp int64) *structs.GSIEvent {
+	t.Helper()
+
+	mapName := s.mapName
+	if mapName == "" {
+		mapName = "de_mirage"
+	}
+	roundPhase := s.roundPhase
+	if roundPhase == "" {
+		roundPhase = "live"
+	}
+	event, err := structs.NewGSIEvent(fmt.Sprintf(`{
+		"provider": {"steamid": %q, "timestamp": %d},
+		"map": {
+			"name": %q, "mode": "competitive", "phase": %q, "round": %d,
+			"team_ct": {"score": %d}, "team_t": {"score": %d}
+		},
+		"round": {"phase": %q},
+		"player": {"steamid": %q, "name": "player", "team": "CT"}
+	}`, steamID, timestamp, mapName, s.phase, s.round, s.scoreCT, s.scoreT, roundPhase, steamID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+func TestLifecycleUpdate(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		steps []step
+		want  []string // events, with matches lettered in the order they start
+	}{
+		{
+			name: "full match",
+			steps: []step{
+				{phase: "warmup"},
+				{phase: "live", round: 0},
+				{phase: "intermission", round: 12, scoreCT: 7, scoreT: 5},
+				{phase: "live", round: 12, scoreCT: 7, scoreT: 5},
+				{phase: "gameover", round: 24, scoreCT: 13, scoreT: 11},
+			},
+			want: []string{"started A", "ended A win"},
+		},
+		{
+			name: "repeated gameover payloads",
+			steps: []step{
+				{phase: "live", round: 0},
+				{phase: "gameover", round: 20, scoreCT: 6, scoreT: 13},
+				{phase: "gameover", round: 20, scoreCT: 6, scoreT: 13},
+				{phase: "gameover", round: 20, scoreCT: 6, scoreT: 13},
+			},
+			want: []string{"started A", "ended A loss"},
+		},
+		{
+			name: "rematch on the same map",
+			steps: []step{
+				{phase: "live", round: 0},
+				{phase: "gameover", round: 20, scoreCT: 13, scoreT: 7},
+				{phase: "warmup"},
+				{phase: "live", round: 0},
+				{phase: "gameover", round: 16, scoreCT: 3, scoreT: 13},
+			},
+			want: []string{"started A", "ended A win", "started B", "ended B loss"},
+		},
+		{
+			name: "joining in progress",
+			steps: []step{
+				{phase: "live", round: 7, scoreCT: 4, scoreT: 3},
+				{phase: "gameover", round: 21, scoreCT: 13, scoreT: 8},
+			},
+			want: []string{"started A in progress", "ended A win"},
+		},
+		{
+			name: "joining at the scoreboard",
+			steps: []step{
+				{phase: "gameover", round: 24, scoreCT: 13, scoreT: 11},
+				{phase: "warmup"},
+			},
+			want: []string{"started B"},
+		},
+		{
+			name: "overtime",
+			steps: []step{
+				{phase: "live", round: 0},
+				{phase: "live", round: 24, roundPhase: "over", scoreCT: 12, scoreT: 12},
+				{phase: "live", round: 24, scoreCT: 12, scoreT: 12},
+				{phase: "live", round: 25, roundPhase: "over", scoreCT: 13, scoreT: 12},
+				{phase: "gameover", round: 30, scoreCT: 16, scoreT: 14},
+			},
+			want: []string{"started A", "ended A win overtime"},
+		},
+		{
+			name: "leaving for another map",
+			steps: []step{
+				{phase: "live", round: 5, scoreCT: 3, scoreT: 2},
+				{mapName: "de_dust2", phase: "warmup"},
+			},
+			want: []string{"started A in progress", "ended A abandoned", "started B"},
+		},
+		{
+			name: "restart",
+			steps: []step{
+				{phase: "live", round: 0},
+				{phase: "live", round: 5, scoreCT: 3, scoreT: 2},
+				{phase: "live", round: 0},
+			},
+			want: []string{"started A", "ended A abandoned", "started B"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var lifecycle Lifecycle
+			letters := make(map[string]string)
+			letter := func(matchID string) string {
+				if _, ok := letters[matchID]; !ok {
+					letters[matchID] = string(rune('A' + len(letters)))
+				}
+				return letters[matchID]
+			}
+			start := time.Unix(1700000000, 0)
+			var got []string
+			for i, s := range tc.steps {
+				now := start.Add(time.Duration(i) * time.Minute)
+				matchEvents := lifecycle.Update(matchPayload(t, s, now.Unix()), steamID, now)
+				for _, event := range matchEvents {
+					description := strings.TrimPrefix(event.Type, "match_") + " " + letter(event.MatchID)
+					if event.JoinedInProgress {
+						description += " in progress"
+					}
+					if event.Result != "" {
+						description += " " + event.Result
+					}
+					if event.Overtime {
+						description += " overtime"
+					}
+					got = append(got, description)
+				}
+				// Matches picked up at the scoreboard are lettered too
+				letter(lifecycle.MatchID)
+			}
+			if strings.Join(got, ", ") != strings.Join(tc.want, ", ") {
+				t.Fatalf("events = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package player_events
+
+import (
+	"time"
+
+	"github.com/LukeyR/CS2-GameStateIntegration/pkg/cs2gsi/structs"
+	"github.com/ukpabik/CSYou/pkg/shared"
+)
+
+// FLASH_ASSIST_WINDOW is how long after a flashbang leaves the inventory an
+// assist is still credited to it.
+const FLASH_ASSIST_WINDOW = 5 * time.Second
+
+// DetectAssistEvents checks for assist deltas and emits RedisAssistEvent(s),
+// flagging assists that closely follow a flashbang throw.
+func (t *Tracker) DetectAssistEvents(matchID string, event *structs.GSIEvent) []*shared.RedisAssistEvent {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state := t.player(event)
+	steamid := event.Player.Steamid
+	assistsNow := event.Player.MatchStats.Assists
+	now := int64(event.Provider.Timestamp)
+
+	// A flashbang leaving the inventory while alive was thrown, unless the
+	// round hasn't started; on death the whole inventory is dropped instead.
+	flashbangs := grenadeCount(event.Player, "weapon_flashbang")
+	if flashbangs < state.flashbangs && *event.Player.State.Health > 0 && !inFreezetime(event) {
+		state.flashThrow, state.hasThrown = now, true
+	}
+	state.flashbangs = flashbangs
+
+	// Counters going backwards means the game reset stats
+	if assistsNow < state.assists {
+		state.assists = 0
+	}
+	prevAssists := state.assists
+	if assistsNow <= prevAssists {
+		return nil
+	}
+	state.assists = assistsNow
+
+	flashAssist := state.hasThrown && now-state.flashThrow <= int64(FLASH_ASSIST_WINDOW/time.Second)
+
+	weaponName, weaponType := "", ""
+	if w := activeWeapon(event.Player); w != nil {
+		weaponName, weaponType = w.Name, string(w.Type)
+	}
+
+	var assistEvents []*shared.RedisAssistEvent
+	for i := prevAssists + 1; i <= assistsNow; i++ {
+		assistEvents = append(assistEvents, &shared.RedisAssistEvent{
+			MatchID:     matchID,
+			Round:       event.CSMap.Round,
+			Map:         event.CSMap.Name,
+			Team:        event.Player.Team,
+			SteamID:     steamid,
+			Name:        event.Player.Name,
+			Mode:        event.CSMap.Mode,
+			EventID:     shared.EventID(shared.EVENT_ASSIST, matchID, event.CSMap.Round, steamid, i),
+			WeaponName:  weaponName,
+			WeaponType:  weaponType,
+			FlashAssist: flashAssist,
+			Timestamp:   int64(event.Provider.Timestamp),
+		})
+	}
+
+	return assistEvents
+}
+
+// grenadeCount returns how many of the named grenade the player holds.
+// GSI lists grenades once, with the count as ammo_reserve.
+func grenadeCount(player *structs.Player, name string) int {
+	count := 0
+	for _, w := range player.Weapons {
+		if w.Name != name {
+			continue
+		}
+		if w.AmmoReserve != nil {
+			count += *w.AmmoReserve
+		} else {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,160 @@
+package player_events
+
+import (
+	"fmt"
+
+	"github.com/LukeyR/CS2-GameStateIntegration/pkg/cs2gsi/structs"
+	"github.com/ukpabik/CSYou/pkg/shared"
+)
+
+// TEAM_SIZES is the number of players per side in each mode, for telling an
+// ace. Modes not listed have five.
+var TEAM_SIZES = map[string]int{
+	"scrimcomp2v2": 2,
+	"wingman":      2,
+}
+
+// badgeState is the player's round so far, for the badges handed out when it
+// closes.
+type badgeState struct {
+	active     bool
+	round      int
+	team       string
+	kills      int
+	headshots  int
+	alive      bool
+	opening    bool
+	entryDeath bool
+	exitFrags  int
+}
+
+// DetectRoundBadges follows the player's kills through a round and, once the
+// round closes, returns a MultiKillEvent if they got two or more kills and a
+// BadgeEvent for each badge earned. Opening kills and entry deaths need alive
+// counts, so are only awarded with allplayers.
+func (t *Tracker) DetectRoundBadges(matchID string, event *structs.GSIEvent, alive TeamsAlive) (*shared.MultiKillEvent, []*shared.BadgeEvent) {
+	// Warmup rounds don't count
+	if event.CSMap.Phase == "warmup" {
+		return nil, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state := t.player(event)
+	bs := &state.badges
+	p := event.Player
+	phase := event.Round.Phase
+	now := int64(event.Provider.Timestamp)
+
+	// Opening kills and entry deaths are the two ends of the opening duel
+	openingLoser := t.recordTeamDeaths(roundOf(event), now, alive).openingLoser(now)
+
+	// A round closes when the next one starts, so kills after it was decided
+	// are still counted, or when the match is over
+	var multiKill *shared.MultiKillEvent
+	var badgeEvents []*shared.BadgeEvent
+	gameOver := event.CSMap.Phase == "gameover"
+	if bs.active && (gameOver || (phase != "over" && event.CSMap.Round != bs.round)) {
+		multiKill, badgeEvents = bs.close(matchID, event)
+	}
+	if gameOver {
+		return multiKill, badgeEvents
+	}
+
+	if !bs.active && phase != "over" {
+		*bs = badgeState{
+			active: true,
+			round:  event.CSMap.Round,
+			alive:  *p.State.Health > 0,
+		}
+	}
+	if !bs.active {
+		return multiKill, badgeEvents
+	}
+
+	bs.team = p.Team
+	roundKills := p.State.RoundKills
+	if newKills := roundKills - bs.kills; newKills > 0 && roundOf(event) == bs.round {
+		switch {
+		case phase == "over":
+			bs.exitFrags += newKills
+		case openingLoser == enemyOf(p.Team):
+			bs.opening = true
+		}
+		bs.kills = roundKills
+		bs.headshots = max(bs.headshots, p.State.RoundKillHS)
+	}
+
+	nowAlive := *p.State.Health > 0
+	if bs.alive && !nowAlive && openingLoser == p.Team && phase != "over" {
+		bs.entryDeath = true
+	}
+	bs.alive = nowAlive
+
+	return multiKill, badgeEvents
+}
+
+// close ends the round and returns its multi-kill and badges.
+func (bs *badgeState) close(matchID string, event *structs.GSIEvent) (*shared.MultiKillEvent, []*shared.BadgeEvent) {
+	bs.active = false
+
+	teamSize, ok := TEAM_SIZES[event.CSMap.Mode]
+	if !ok {
+		teamSize = 5
+	}
+	ace := bs.kills >= teamSize
+
+	var multiKill *shared.MultiKillEvent
+	if bs.kills >= 2 {
+		label := fmt.Sprintf("%dk", bs.kills)
+		if ace {
+			label = shared.BADGE_ACE
+		}
+		multiKill = &shared.MultiKillEvent{
+			MatchID:   matchID,
+			Round:     bs.round,
+			Map:       event.CSMap.Name,
+			Team:      bs.team,
+			SteamID:   event.Player.Steamid,
+			Name:      event.Player.Name,
+			Mode:      event.CSMap.Mode,
+			EventID:   shared.EventID(shared.EVENT_MULTI_KILL, matchID, bs.round, event.Player.Steamid, nil),
+			Kills:     bs.kills,
+			Headshots: bs.headshots,
+			Label:     label,
+			Timestamp: int64(event.Provider.Timestamp),
+		}
+	}
+
+	var badgeEvents []*shared.BadgeEvent
+	award := func(badge string, count int) {
+		badgeEvents = append(badgeEvents, &shared.BadgeEvent{
+			MatchID:   matchID,
+			Round:     bs.round,
+			Map:       event.CSMap.Name,
+			Team:      bs.team,
+			SteamID:   event.Player.Steamid,
+			Name:      event.Player.Name,
+			Mode:      event.CSMap.Mode,
+			EventID:   shared.EventID(shared.EVENT_BADGE, matchID, bs.round, event.Player.Steamid, badge),
+			Badge:     badge,
+			Count:     count,
+			Timestamp: int64(event.Provider.Timestamp),
+		})
+	}
+	if ace {
+		award(shared.BADGE_ACE, 1)
+	}
+	if bs.opening {
+		award(shared.BADGE_OPENING_KILL, 1)
+	}
+	if bs.entryDeath {
+		award(shared.BADGE_ENTRY_DEATH, 1)
+	}
+	if bs.exitFrags > 0 {
+		award(shared.BADGE_EXIT_FRAG, bs.exitFrags)
+	}
+
+	return multiKill, badgeEvents
+}
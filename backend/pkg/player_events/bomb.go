@@ -0,0 +1,138 @@
+package player_events
+
+import (
+	"fmt"
+
+	"github.com/LukeyR/CS2-GameStateIntegration/pkg/cs2gsi/structs"
+	"github.com/ukpabik/CSYou/pkg/shared"
+)
+
+// Bomb is the GSI bomb block. It is only sent to spectators and GOTV.
+type Bomb struct {
+	State     string `json:"state"` // carried, dropped, planting, planted, defusing, defused, exploded
+	Position  string `json:"position"`
+	Player    string `json:"player"` // steamid of the carrier, planter or defuser
+	Countdown string `json:"countdown"`
+}
+
+// bombState is the bomb as last seen from a player's payloads.
+type bombState struct {
+	state     string // bomb block state
+	actor     string // bomb block player
+	roundBomb string // round.bomb
+	hasC4     bool
+}
+
+// DetectBombEvents follows the bomb through a round and emits a BombEvent for
+// every change. With the bomb block the whole lifecycle is visible; without
+// it, only the player's own carrying and planting, and the round's bomb
+// state, can be seen.
+func (t *Tracker) DetectBombEvents(matchID string, event *structs.GSIEvent, bomb *Bomb) []*shared.BombEvent {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state := t.player(event)
+	bs := &state.bomb
+	steamid := event.Player.Steamid
+
+	newEvent := func(action, actor, position string) *shared.BombEvent {
+		return &shared.BombEvent{
+			MatchID:   matchID,
+			Round:     event.CSMap.Round,
+			Map:       event.CSMap.Name,
+			Team:      event.Player.Team,
+			SteamID:   steamid,
+			Name:      event.Player.Name,
+			Mode:      event.CSMap.Mode,
+			EventID:   shared.EventID(shared.EVENT_BOMB, matchID, event.CSMap.Round, steamid, fmt.Sprintf("%s:%s:%d", action, actor, event.Provider.Timestamp)),
+			Action:    action,
+			Actor:     actor,
+			ByPlayer:  actor != "" && actor == steamid,
+			Site:      "", // GSI doesn't say which site, only the position
+			Position:  position,
+			Timestamp: int64(event.Provider.Timestamp),
+		}
+	}
+
+	var bombEvents []*shared.BombEvent
+	if bomb != nil {
+		prevState, prevActor := bs.state, bs.actor
+		bs.state, bs.actor = bomb.State, bomb.Player
+		bs.roundBomb = event.Round.Bomb
+		bs.hasC4 = hasWeapon(event.Player, "weapon_c4")
+
+		position := ""
+		if bomb.State != "carried" {
+			position = bomb.Position
+		}
+
+		switch {
+		case bomb.State == prevState && bomb.Player == prevActor:
+		case bomb.State == "carried" && bomb.Player != prevActor:
+			bombEvents = append(bombEvents, newEvent(shared.BOMB_PICKED_UP, bomb.Player, position))
+		case bomb.State == "dropped" && prevState != "dropped":
+			bombEvents = append(bombEvents, newEvent(shared.BOMB_DROPPED, prevActor, position))
+		case bomb.State == "planting", bomb.State == "defusing":
+			bombEvents = append(bombEvents, newEvent(bomb.State, bomb.Player, position))
+		case bomb.State == "planted" && prevState != "planted" && prevState != "defusing":
+			// The planter is only known if the plant was seen starting
+			actor := ""
+			if prevState == "planting" {
+				actor = prevActor
+			}
+			bombEvents = append(bombEvents, newEvent(shared.BOMB_PLANTED, actor, position))
+		case bomb.State == "defused":
+			actor := ""
+			if prevState == "defusing" {
+				actor = prevActor
+			}
+			bombEvents = append(bombEvents, newEvent(shared.BOMB_DEFUSED, actor, position))
+		case bomb.State == "exploded":
+			bombEvents = append(bombEvents, newEvent(shared.BOMB_EXPLODED, "", position))
+		}
+
+		return bombEvents
+	}
+
+	// Without the bomb block, the player's own C4 shows carrying and planting
+	hasC4 := hasWeapon(event.Player, "weapon_c4")
+	roundBomb := event.Round.Bomb
+	plantedNow := roundBomb == "planted" && bs.roundBomb != "planted"
+
+	switch {
+	case hasC4 && !bs.hasC4:
+		bombEvents = append(bombEvents, newEvent(shared.BOMB_PICKED_UP, steamid, ""))
+	case !hasC4 && bs.hasC4 && plantedNow:
+		bombEvents = append(bombEvents, newEvent(shared.BOMB_PLANTED, steamid, ""))
+		plantedNow = false
+	case !hasC4 && bs.hasC4 && roundBomb == "" && event.Round.Phase != "freezetime":
+		// C4 is handed out again at the start of each round
+		bombEvents = append(bombEvents, newEvent(shared.BOMB_DROPPED, steamid, ""))
+	}
+	bs.hasC4 = hasC4
+
+	if roundBomb != bs.roundBomb {
+		switch {
+		case plantedNow:
+			bombEvents = append(bombEvents, newEvent(shared.BOMB_PLANTED, "", ""))
+		case roundBomb == "defused":
+			bombEvents = append(bombEvents, newEvent(shared.BOMB_DEFUSED, "", ""))
+		case roundBomb == "exploded":
+			bombEvents = append(bombEvents, newEvent(shared.BOMB_EXPLODED, "", ""))
+		}
+	}
+	bs.roundBomb = roundBomb
+	bs.state, bs.actor = "", ""
+
+	return bombEvents
+}
+
+// hasWeapon reports whether the player holds the named weapon.
+func hasWeapon(player *structs.Player, name string) bool {
+	for _, w := range player.Weapons {
+		if w.Name == name {
+			return true
+		}
+	}
+	return false
+}
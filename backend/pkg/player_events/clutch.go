@@ -0,0 +1,121 @@
+package player_events
+
+import (
+	"fmt"
+
+	"github.com/LukeyR/CS2-GameStateIntegration/pkg/cs2gsi/structs"
+	"github.com/ukpabik/CSYou/pkg/shared"
+)
+
+// TeamsAlive is how many players are alive on each side.
+type TeamsAlive struct {
+	CT    int
+	T     int
+	Known bool // alive counts need allplayers, so are only known to spectators and GOTV
+}
+
+// enemies returns the number of alive players on the other side to team.
+func (ta TeamsAlive) enemies(team string) int {
+	if team == "T" {
+		return ta.CT
+	}
+	return ta.T
+}
+
+// teammates returns the number of alive players on team, the player included.
+func (ta TeamsAlive) teammates(team string) int {
+	if team == "T" {
+		return ta.T
+	}
+	return ta.CT
+}
+
+// clutchState is a clutch the player is in.
+type clutchState struct {
+	active bool
+	round  int
+	team   string
+	vs     int
+	kills  int // round kills when the clutch started
+	last   int // round kills at the last kill event
+}
+
+// DetectClutchEvents follows the player through a round and emits a
+// ClutchEvent when they become the last player alive on their team, for each
+// kill they make from then on, and for the outcome once the round is decided.
+func (t *Tracker) DetectClutchEvents(matchID string, event *structs.GSIEvent, alive TeamsAlive) []*shared.ClutchEvent {
+	// Warmup rounds don't count
+	if event.CSMap.Phase == "warmup" {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state := t.player(event)
+	cs := &state.clutch
+	p := event.Player
+	roundKills := p.State.RoundKills
+
+	newEvent := func(action string, kills int) *shared.ClutchEvent {
+		enemies := -1
+		if alive.Known {
+			enemies = alive.enemies(cs.team)
+		}
+		return &shared.ClutchEvent{
+			MatchID:   matchID,
+			Round:     cs.round,
+			Map:       event.CSMap.Name,
+			Team:      cs.team,
+			SteamID:   p.Steamid,
+			Name:      p.Name,
+			Mode:      event.CSMap.Mode,
+			EventID:   shared.EventID(shared.EVENT_CLUTCH, matchID, cs.round, p.Steamid, fmt.Sprintf("%s:%d", action, kills)),
+			Action:    action,
+			Vs:        cs.vs,
+			Enemies:   enemies,
+			Kills:     kills,
+			Timestamp: int64(event.Provider.Timestamp),
+		}
+	}
+
+	var clutchEvents []*shared.ClutchEvent
+	if cs.active {
+		// Kills only count within the clutch round
+		if roundOf(event) == cs.round && roundKills > cs.last {
+			cs.last = roundKills
+			clutchEvents = append(clutchEvents, newEvent(shared.CLUTCH_KILL, roundKills-cs.kills))
+		}
+
+		// The clutch is decided with the round, even if the player died
+		if winTeam, _ := roundWinner(event, cs.round); winTeam != "" {
+			action := shared.CLUTCH_LOST
+			if winTeam == cs.team {
+				action = shared.CLUTCH_WON
+			}
+			clutchEvents = append(clutchEvents, newEvent(action, cs.last-cs.kills))
+			cs.active = false
+		} else if roundOf(event) != cs.round {
+			// The round went by without its outcome being seen
+			cs.active = false
+		}
+		return clutchEvents
+	}
+
+	if !alive.Known || event.Round.Phase != "live" || *p.State.Health == 0 {
+		return nil
+	}
+	if alive.teammates(p.Team) != 1 || alive.enemies(p.Team) == 0 {
+		return nil
+	}
+
+	*cs = clutchState{
+		active: true,
+		round:  event.CSMap.Round,
+		team:   p.Team,
+		vs:     alive.enemies(p.Team),
+		kills:  roundKills,
+		last:   roundKills,
+	}
+	return append(clutchEvents, newEvent(shared.CLUTCH_STARTED, 0))
+}
@@ -0,0 +1,94 @@
+package player_events
+
+import (
+	"fmt"
+
+	"github.com/LukeyR/CS2-GameStateIntegration/pkg/cs2gsi/structs"
+	"github.com/ukpabik/CSYou/pkg/shared"
+)
+
+// FIGHT_GAP is how long, in seconds, a player must go without taking damage
+// before the next hit counts as the start of a new fight.
+var FIGHT_GAP int64 = 5
+
+// damageState is a player's health and armor as last seen, and the fight
+// they are in.
+type damageState struct {
+	seen   bool
+	round  int
+	health int
+	armor  int
+
+	lastHit     int64 // provider timestamp of the last hit, 0 outside a fight
+	fightHealth int
+	fightHits   int
+}
+
+// DetectDamageEvents diffs the player's health and armor against the last
+// payload and emits a DamageEvent when either drops within a round.
+func (t *Tracker) DetectDamageEvents(matchID string, event *structs.GSIEvent, clock RoundClock) *shared.DamageEvent {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state := t.player(event)
+	ds := &state.damage
+	p := event.Player
+	health := *p.State.Health
+	armor := *p.State.Armor
+	round := roundOf(event)
+	timestamp := int64(event.Provider.Timestamp)
+
+	prevHealth, prevArmor, seen := ds.health, ds.armor, ds.seen
+	ds.health, ds.armor, ds.seen = health, armor, true
+
+	// Health and armor are topped up between rounds, so fights don't carry over
+	if round != ds.round {
+		ds.round = round
+		ds.lastHit = 0
+	}
+
+	// Warmup damage doesn't count
+	if !seen || prevHealth == 0 || event.CSMap.Phase == "warmup" {
+		return nil
+	}
+
+	healthDamage := max(prevHealth-health, 0)
+	armorDamage := max(prevArmor-armor, 0)
+	if health == 0 {
+		// Armor is cleared on death, what the last hit took is unknown
+		armorDamage = 0
+	}
+	if healthDamage == 0 && armorDamage == 0 {
+		return nil
+	}
+
+	fightStart := ds.lastHit == 0 || timestamp-ds.lastHit >= FIGHT_GAP
+	if fightStart {
+		ds.fightHealth = prevHealth
+		ds.fightHits = 0
+	}
+	ds.fightHits++
+	ds.lastHit = timestamp
+
+	return &shared.DamageEvent{
+		MatchID:      matchID,
+		Round:        round,
+		Map:          event.CSMap.Name,
+		Team:         p.Team,
+		SteamID:      p.Steamid,
+		Name:         p.Name,
+		Mode:         event.CSMap.Mode,
+		EventID:      shared.EventID(shared.EVENT_DAMAGE, matchID, round, p.Steamid, fmt.Sprintf("%d:%d:%d", timestamp, health, armor)),
+		HealthDamage: healthDamage,
+		ArmorDamage:  armorDamage,
+		Health:       health,
+		Armor:        armor,
+		Fatal:        health == 0,
+		FightStart:   fightStart,
+		FightHealth:  ds.fightHealth,
+		FightHits:    ds.fightHits,
+		RoundPhase:   clock.Phase,
+		RoundClock:   clock.Seconds,
+		Timestamp:    timestamp,
+	}
+}
@@ -0,0 +1,93 @@
+package player_events
+
+import (
+	"github.com/LukeyR/CS2-GameStateIntegration/pkg/cs2gsi/structs"
+	"github.com/ukpabik/CSYou/pkg/shared"
+)
+
+// RoundClock is the round timer shown in game when a payload arrived.
+type RoundClock struct {
+	Phase   string  // live, bomb, defuse, ...
+	Seconds float64 // seconds left in the phase, -1 if unknown
+}
+
+// loadout is what a player was carrying in their last payload while alive.
+type loadout struct {
+	weaponName string
+	weaponType string
+	equipValue int
+}
+
+// DetectDeathEvents checks for death deltas, or health dropping to 0, and
+// emits RedisDeathEvent(s).
+func (t *Tracker) DetectDeathEvents(matchID string, event *structs.GSIEvent, clock RoundClock) []*shared.RedisDeathEvent {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state := t.player(event)
+	steamid := event.Player.Steamid
+	deathsNow := event.Player.MatchStats.Deaths
+	healthNow := *event.Player.State.Health
+
+	// Counters going backwards means the game reset stats
+	if deathsNow < state.deaths {
+		state.deaths = 0
+	}
+	prevDeaths := state.deaths
+	prevHealth, seen := state.health, state.seenHealth
+	state.health, state.seenHealth = healthNow, true
+
+	// Health reaching 0 usually lands a payload before the death counter
+	newDeaths := deathsNow - prevDeaths
+	if newDeaths <= 0 && seen && prevHealth > 0 && healthNow == 0 {
+		newDeaths = 1
+	}
+	state.deaths = max(prevDeaths+newDeaths, deathsNow)
+
+	// Weapons are gone once dead, so fall back to the last loadout
+	gear := state.loadout
+	if healthNow > 0 {
+		gear = loadout{equipValue: event.Player.State.EquipValue}
+		if w := activeWeapon(event.Player); w != nil {
+			gear.weaponName, gear.weaponType = w.Name, string(w.Type)
+		}
+		state.loadout = gear
+	}
+
+	if newDeaths <= 0 {
+		return nil
+	}
+
+	var deathEvents []*shared.RedisDeathEvent
+	for i := 0; i < newDeaths; i++ {
+		deathEvents = append(deathEvents, &shared.RedisDeathEvent{
+			MatchID:     matchID,
+			Round:       event.CSMap.Round,
+			Map:         event.CSMap.Name,
+			Team:        event.Player.Team,
+			SteamID:     steamid,
+			Name:        event.Player.Name,
+			Mode:        event.CSMap.Mode,
+			EventID:     shared.EventID(shared.EVENT_DEATH, matchID, event.CSMap.Round, steamid, prevDeaths+i+1),
+			RoundPhase:  clock.Phase,
+			RoundClock:  clock.Seconds,
+			WeaponName:  gear.weaponName,
+			WeaponType:  gear.weaponType,
+			EquipValue:  gear.equipValue,
+			BombPlanted: event.Round != nil && event.Round.Bomb == "planted",
+			Timestamp:   int64(event.Provider.Timestamp),
+		})
+	}
+
+	return deathEvents
+}
+
+// activeWeapon returns the weapon the player is holding, if any.
+func activeWeapon(player *structs.Player) *structs.Weapon {
+	for _, w := range player.Weapons {
+		if w.State == structs.WeaponStateActive {
+			return w
+		}
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package player_events
+
+import (
+	"github.com/LukeyR/CS2-GameStateIntegration/pkg/cs2gsi/structs"
+	"github.com/ukpabik/CSYou/pkg/shared"
+)
+
+// TRADE_WINDOW is how long, in seconds, after a player dies a teammate has to
+// kill an enemy for the death to count as traded: a kill at t trades deaths
+// in t-TRADE_WINDOW < death <= t.
+var TRADE_WINDOW int64 = 5
+
+// teamDeaths is when each side lost players in one round, taken from the
+// alive counts.
+type teamDeaths struct {
+	round int
+	alive TeamsAlive
+	at    map[string][]int64 // provider timestamps of deaths, by side
+	first string             // side that died first
+}
+
+// died returns whether side lost a player in from < t <= to.
+func (td *teamDeaths) died(side string, from, to int64) bool {
+	for _, t := range td.at[side] {
+		if t > from && t <= to {
+			return true
+		}
+	}
+	return false
+}
+
+// openingLoser returns the side that lost the round's opening duel if it was
+// fought in the payload at now, or "" if not. The opening duel is the
+// round's first death, when no other on that side was seen with it.
+func (td *teamDeaths) openingLoser(now int64) string {
+	if td.first == "" || len(td.at[td.first]) != 1 || td.at[td.first][0] != now {
+		return ""
+	}
+	return td.first
+}
+
+// duelState is a death of the player whose trade is still open.
+type duelState struct {
+	pending bool
+	round   int
+	team    string
+	at      int64
+	opening bool
+	deathID string // EventID of the death
+}
+
+// DetectDuelEvents emits a DuelEvent for each of the player's kills, saying
+// whether it was the round's opening kill and whether it traded a teammate,
+// and for each death once it is known whether a teammate traded it. Both
+// need alive counts from allplayers; without them the events are marked as
+// untracked.
+func (t *Tracker) DetectDuelEvents(matchID string, event *structs.GSIEvent, kills []*shared.RedisKillEvent, deaths []*shared.RedisDeathEvent, alive TeamsAlive) []*shared.DuelEvent {
+	// Warmup rounds don't count
+	if event.CSMap.Phase == "warmup" {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state := t.player(event)
+	ds := &state.duel
+	p := event.Player
+	round := roundOf(event)
+	now := int64(event.Provider.Timestamp)
+	td := t.recordTeamDeaths(round, now, alive)
+
+	// Each duel event is one kill or death, so takes its ID from it
+	newEvent := func(kind string, round int, team string, at int64, of string) *shared.DuelEvent {
+		return &shared.DuelEvent{
+			MatchID:   matchID,
+			Round:     round,
+			Map:       event.CSMap.Name,
+			Team:      team,
+			SteamID:   p.Steamid,
+			Name:      p.Name,
+			Mode:      event.CSMap.Mode,
+			EventID:   shared.EventID(shared.EVENT_DUEL, matchID, round, p.Steamid, of),
+			Kind:      kind,
+			Tracked:   alive.Known,
+			Window:    TRADE_WINDOW,
+			Timestamp: at,
+		}
+	}
+
+	var duelEvents []*shared.DuelEvent
+
+	// Close out a death once its window has passed or an enemy went down in
+	// it, which is ds.at <= t < ds.at+TRADE_WINDOW
+	if ds.pending {
+		deathLog := t.deaths[ds.round]
+		traded := deathLog != nil && deathLog.died(enemyOf(ds.team), ds.at-1, ds.at+TRADE_WINDOW-1)
+		if traded || round != ds.round || now >= ds.at+TRADE_WINDOW {
+			de := newEvent(shared.DUEL_DEATH, ds.round, ds.team, ds.at, ds.deathID)
+			de.Opening = ds.opening
+			de.Trade = traded
+			de.Tracked = deathLog != nil && deathLog.alive.Known
+			duelEvents = append(duelEvents, de)
+			ds.pending = false
+		}
+	}
+
+	openingLoser := td.openingLoser(now)
+	for _, kill := range kills {
+		// The bomb going off isn't a duel
+		if kill.ActiveGun.Type == "C4" {
+			continue
+		}
+		ke := newEvent(shared.DUEL_KILL, round, p.Team, kill.Timestamp, kill.EventID)
+		ke.Opening = openingLoser == enemyOf(p.Team)
+		ke.Trade = alive.Known && td.died(p.Team, kill.Timestamp-TRADE_WINDOW, kill.Timestamp)
+		duelEvents = append(duelEvents, ke)
+	}
+	if len(deaths) > 0 {
+		death := deaths[len(deaths)-1]
+		*ds = duelState{
+			pending: true,
+			round:   round,
+			team:    p.Team,
+			at:      death.Timestamp,
+			opening: openingLoser == p.Team,
+			deathID: death.EventID,
+		}
+	}
+
+	return duelEvents
+}
+
+// recordTeamDeaths updates the round's death log from the alive counts and
+// returns it. Must be called with t.mu held.
+func (t *Tracker) recordTeamDeaths(round int, now int64, alive TeamsAlive) *teamDeaths {
+	td, ok := t.deaths[round]
+	if !ok {
+		td = &teamDeaths{round: round, alive: alive, at: make(map[string][]int64)}
+		t.deaths[round] = td
+
+		// Only the previous round is still needed, for trades across the end
+		for r := range t.deaths {
+			if r < round-1 || r > round {
+				delete(t.deaths, r)
+			}
+		}
+		return td
+	}
+	if !alive.Known {
+		return td
+	}
+
+	// Every view of a payload carries the same counts, so only the first
+	// sees them drop
+	for _, side := range []string{"CT", "T"} {
+		lost := td.alive.teammates(side) - alive.teammates(side)
+		for range max(lost, 0) {
+			td.at[side] = append(td.at[side], now)
+		}
+		if lost > 0 && td.first == "" {
+			td.first = side
+		}
+	}
+	td.alive = alive
+
+	return td
+}
+
+// enemyOf returns the other side to team.
+func enemyOf(team string) string {
+	if team == "T" {
+		return "CT"
+	}
+	return "T"
+}
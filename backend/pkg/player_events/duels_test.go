@@ -0,0 +1,79 @@
+package player_events
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/LukeyR/CS2-GameStateIntegration/pkg/cs2gsi/structs"
+	"github.com/ukpabik/CSYou/pkg/shared"
+)
+
+// duelPayload is a live round payload for a CT player, stamped at timestamp.
+func duelPayload(t *testing.T, timestamp int64) *structs.GSIEvent {
+	t.Helper()
+
+	event, err := structs.NewGSIEvent(fmt.Sprintf(`{
+		"provider": {"steamid": "76561198000000001", "timestamp": %d},
+		"map": {"name": "de_mirage", "mode": "competitive", "phase": "live", "round": 3},
+		"round": {"phase": "live"},
+		"player": {
+			"steamid": "76561198000000001",
+			"name": "player",
+			"team": "CT",
+			"state": {"health": 100, "money": 800}
+		}
+	}`, timestamp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+// tradeAfter reports whether a kill gap seconds after a teammate's death
+// counted as a trade.
+func tradeAfter(t *testing.T, gap int64) bool {
+	t.Helper()
+
+	tracker := NewTracker()
+	start := int64(1700000000)
+	tracker.DetectDuelEvents("match", duelPayload(t, start), nil, nil, TeamsAlive{CT: 5, T: 5, Known: true})
+	tracker.DetectDuelEvents("match", duelPayload(t, start+1), nil, nil, TeamsAlive{CT: 4, T: 5, Known: true})
+
+	at := start + 1 + gap
+	kill := &shared.RedisKillEvent{EventID: "kill", Timestamp: at}
+	duels := tracker.DetectDuelEvents("match", duelPayload(t, at), []*shared.RedisKillEvent{kill}, nil, TeamsAlive{CT: 4, T: 4, Known: true})
+	if len(duels) != 1 {
+		t.Fatalf("expected one duel event, got %+v", duels)
+	}
+	return duels[0].Trade
+}
+
+func TestTradeWindow(t *testing.T) {
+	for gap, want := range map[int64]bool{
+		0:                true,
+		TRADE_WINDOW - 1: true,
+		TRADE_WINDOW:     false,
+	} {
+		if got := tradeAfter(t, gap); got != want {
+			t.Errorf("kill %ds after the death: trade = %v, want %v", gap, got, want)
+		}
+	}
+}
+
+func TestOpeningDuel(t *testing.T) {
+	tracker := NewTracker()
+	start := int64(1700000000)
+	tracker.DetectDuelEvents("match", duelPayload(t, start), nil, nil, TeamsAlive{CT: 5, T: 5, Known: true})
+
+	kill := &shared.RedisKillEvent{EventID: "kill", Timestamp: start + 1}
+	duels := tracker.DetectDuelEvents("match", duelPayload(t, start+1), []*shared.RedisKillEvent{kill}, nil, TeamsAlive{CT: 5, T: 4, Known: true})
+	if len(duels) != 1 || !duels[0].Opening {
+		t.Fatalf("expected an opening kill, got %+v", duels)
+	}
+
+	kill = &shared.RedisKillEvent{EventID: "second kill", Timestamp: start + 2}
+	duels = tracker.DetectDuelEvents("match", duelPayload(t, start+2), []*shared.RedisKillEvent{kill}, nil, TeamsAlive{CT: 5, T: 3, Known: true})
+	if len(duels) != 1 || duels[0].Opening {
+		t.Fatalf("expected a kill after the opening duel, got %+v", duels)
+	}
+}
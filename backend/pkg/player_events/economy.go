@@ -0,0 +1,60 @@
+package player_events
+
+import (
+	"github.com/LukeyR/CS2-GameStateIntegration/pkg/cs2gsi/structs"
+	"github.com/ukpabik/CSYou/pkg/shared"
+)
+
+// economyState is the part of a player's economy last sent, so updates only
+// go out when it changes.
+type economyState struct {
+	round      int
+	losses     int
+	buyType    string
+	canFullBuy bool
+	badBuy     string
+}
+
+// DetectTeamEconomy returns the player's economy for the current round when
+// their buy type, forecast or team's losing streak changes, or nil. It reads
+// the round DetectRoundSummary tracks, so must be called after it.
+func (t *Tracker) DetectTeamEconomy(matchID string, event *structs.GSIEvent) *shared.TeamEconomy {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state := t.player(event)
+	rs := &state.round
+	if !rs.active || !rs.buySeen {
+		return nil
+	}
+
+	forecast := rs.forecast(event.CSMap.Mode)
+	current := economyState{
+		round:      rs.round,
+		losses:     rs.buyLosses,
+		buyType:    forecast.BuyType,
+		canFullBuy: forecast.CanFullBuy,
+		badBuy:     forecast.BadBuy,
+	}
+	if current == state.economy {
+		return nil
+	}
+	state.economy = current
+
+	return &shared.TeamEconomy{
+		MatchID:        matchID,
+		Round:          rs.round,
+		Team:           rs.team,
+		SteamID:        event.Player.Steamid,
+		Name:           event.Player.Name,
+		Losses:         rs.buyLosses,
+		LossBonus:      forecast.LossBonus,
+		Money:          rs.buyMoney,
+		EquipValue:     rs.buyEquip,
+		BuyType:        forecast.BuyType,
+		MinNextMoney:   forecast.MinNextMoney,
+		CanFullBuyNext: forecast.CanFullBuy,
+		BadBuy:         forecast.BadBuy,
+		Timestamp:      int64(event.Provider.Timestamp),
+	}
+}
@@ -1,22 +1,26 @@
 package player_events
 
 import (
-	"time"
-
 	"github.com/LukeyR/CS2-GameStateIntegration/pkg/cs2gsi/structs"
 	"github.com/ukpabik/CSYou/pkg/shared"
 )
 
-// Track last known kills per player
-var lastKills = make(map[string]int)
-
 // DetectKillEvents checks for kill deltas and emits RedisKillEvent(s).
-func DetectKillEvents(matchID string, event *structs.GSIEvent) []*shared.RedisKillEvent {
+func (t *Tracker) DetectKillEvents(matchID string, event *structs.GSIEvent) []*shared.RedisKillEvent {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state := t.player(event)
 	killsNow := event.Player.MatchStats.Kills
 	steamid := event.Player.Steamid
 
-	// Get last recorded kills (default 0 if not tracked yet)
-	prevKills := lastKills[steamid]
+	// Counters going backwards means the game reset stats
+	if killsNow < state.kills {
+		state.kills = 0
+	}
+	prevKills := state.kills
+
+	headshots, exact := state.attributeHeadshots(event, killsNow-prevKills)
 
 	if killsNow <= prevKills {
 		return nil
@@ -24,42 +28,71 @@ func DetectKillEvents(matchID string, event *structs.GSIEvent) []*shared.RedisKi
 
 	// Find active gun
 	active := shared.ActiveGun{}
-	for _, w := range event.Player.Weapons {
-		if w.State == "active" {
-			active = shared.ActiveGun{
-				Name:     w.Name,
-				Type:     string(w.Type),
-				Skin:     w.Paintkit,
-				Headshot: event.Player.State.RoundKillHS > 0,
-			}
-			if w.AmmoClip != nil {
-				active.Ammo = *w.AmmoClip
-			}
-			if w.AmmoReserve != nil {
-				active.Reserve = *w.AmmoReserve
-			}
-			break
+	if w := activeWeapon(event.Player); w != nil {
+		active = shared.ActiveGun{
+			Name: w.Name,
+			Type: string(w.Type),
+			Skin: w.Paintkit,
+		}
+		if w.AmmoClip != nil {
+			active.Ammo = *w.AmmoClip
+		}
+		if w.AmmoReserve != nil {
+			active.Reserve = *w.AmmoReserve
 		}
 	}
 
 	// Generate kill events for each new kill
 	var killEvents []*shared.RedisKillEvent
 	for i := prevKills + 1; i <= killsNow; i++ {
+		gun := active
+		// When only some of the kills were headshots, their order is unknown
+		gun.Headshot = i-prevKills <= headshots
+
 		killEvents = append(killEvents, &shared.RedisKillEvent{
-			MatchID:   matchID,
-			Round:     event.CSMap.Round,
-			Map:       event.CSMap.Name,
-			Team:      event.Player.Team,
-			SteamID:   steamid,
-			Name:      event.Player.Name,
-			Mode:      event.CSMap.Mode,
-			ActiveGun: active,
-			Timestamp: time.Now().Unix(),
+			MatchID:       matchID,
+			Round:         event.CSMap.Round,
+			Map:           event.CSMap.Name,
+			Team:          event.Player.Team,
+			SteamID:       steamid,
+			Name:          event.Player.Name,
+			Mode:          event.CSMap.Mode,
+			EventID:       shared.EventID(shared.EVENT_KILL, matchID, event.CSMap.Round, steamid, i),
+			ActiveGun:     gun,
+			HeadshotExact: exact,
+			Timestamp:     int64(event.Provider.Timestamp),
 		})
 	}
 
-	// Update lastKills cache
-	lastKills[steamid] = killsNow
+	// Update last kills
+	state.kills = killsNow
 
 	return killEvents
 }
+
+// attributeHeadshots works out how many of newKills were headshots from the
+// change in round_killhs since the last payload, and whether that is exact.
+// It is only ambiguous when some, but not all, of several kills in one
+// payload were headshots, or the round counters don't line up with the kills.
+func (state *playerState) attributeHeadshots(event *structs.GSIEvent, newKills int) (int, bool) {
+	roundKills := event.Player.State.RoundKills
+	roundKillHS := event.Player.State.RoundKillHS
+
+	prevRoundKills, prevRoundKillHS := state.roundKills, state.roundKillHS
+	if roundKills < prevRoundKills || roundKillHS < prevRoundKillHS {
+		// New round, counters were reset
+		prevRoundKills, prevRoundKillHS = 0, 0
+	}
+	state.roundKills = roundKills
+	state.roundKillHS = roundKillHS
+
+	if newKills <= 0 {
+		return 0, true
+	}
+
+	headshots := min(max(roundKillHS-prevRoundKillHS, 0), newKills)
+	exact := roundKills-prevRoundKills == newKills &&
+		(headshots == 0 || headshots == newKills)
+
+	return headshots, exact
+}
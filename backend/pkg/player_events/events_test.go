@@ -0,0 +1,115 @@
+package player_events
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/LukeyR/CS2-GameStateIntegration/pkg/cs2gsi/structs"
+)
+
+// killCounters are the kill counters a payload reports.
+type killCounters struct {
+	kills, roundKills, roundKillHS int
+}
+
+// killPayload is a live round payload reporting counters.
+func killPayload(t *testing.T, counters killCounters) *structs.GSIEvent {
+	t.Helper()
+
+	event, err := structs.NewGSIEvent(fmt.Sprintf(`{
+		"provider": {"steamid": "76561198000000001", "timestamp": 1700000000},
+		"map": {"name": "de_mirage", "mode": "competitive", "phase": "live", "round": 3},
+		"round": {"phase": "live"},
+		"player": {
+			"steamid": "76561198000000001",
+			"name": "player",
+			"team": "CT",
+			"state": {"health": 100, "round_kills": %d, "round_killhs": %d},
+			"match_stats": {"kills": %d}
+		}
+	}`, counters.roundKills, counters.roundKillHS, counters.kills))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+func TestHeadshotAttribution(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		before    killCounters // last payload seen
+		after     killCounters
+		headshots []bool
+		exact     bool
+	}{
+		{
+			name:      "one headshot",
+			after:     killCounters{kills: 1, roundKills: 1, roundKillHS: 1},
+			headshots: []bool{true},
+			exact:     true,
+		},
+		{
+			name:      "one body kill",
+			before:    killCounters{kills: 1, roundKills: 1, roundKillHS: 1},
+			after:     killCounters{kills: 2, roundKills: 2, roundKillHS: 1},
+			headshots: []bool{false},
+			exact:     true,
+		},
+		{
+			name:      "every kill in the payload a headshot",
+			after:     killCounters{kills: 2, roundKills: 2, roundKillHS: 2},
+			headshots: []bool{true, true},
+			exact:     true,
+		},
+		{
+			name:      "no kill in the payload a headshot",
+			after:     killCounters{kills: 3, roundKills: 3, roundKillHS: 0},
+			headshots: []bool{false, false, false},
+			exact:     true,
+		},
+		{
+			name:      "some kills in the payload headshots",
+			after:     killCounters{kills: 3, roundKills: 3, roundKillHS: 1},
+			headshots: []bool{true, false, false},
+			exact:     false,
+		},
+		{
+			name:      "headshot in a new round",
+			before:    killCounters{kills: 3, roundKills: 3, roundKillHS: 2},
+			after:     killCounters{kills: 4, roundKills: 1, roundKillHS: 1},
+			headshots: []bool{true},
+			exact:     true,
+		},
+		{
+			name:      "body kill in a new round",
+			before:    killCounters{kills: 3, roundKills: 3, roundKillHS: 2},
+			after:     killCounters{kills: 4, roundKills: 1, roundKillHS: 0},
+			headshots: []bool{false},
+			exact:     true,
+		},
+		{
+			name:      "round counters behind the kills",
+			after:     killCounters{kills: 2, roundKills: 1, roundKillHS: 1},
+			headshots: []bool{true, false},
+			exact:     false,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tracker := NewTracker()
+			tracker.DetectKillEvents("match", killPayload(t, tc.before))
+
+			kills := tracker.DetectKillEvents("match", killPayload(t, tc.after))
+			if len(kills) != len(tc.headshots) {
+				t.Fatalf("expected %d kill(s), got %d", len(tc.headshots), len(kills))
+			}
+			for i, kill := range kills {
+				if kill.ActiveGun.Headshot != tc.headshots[i] {
+					t.Errorf("kill %d: headshot = %v, want %v", i, kill.ActiveGun.Headshot, tc.headshots[i])
+				}
+				if kill.HeadshotExact != tc.exact {
+					t.Errorf("kill %d: exact = %v, want %v", i, kill.HeadshotExact, tc.exact)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package player_events
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/LukeyR/CS2-GameStateIntegration/pkg/cs2gsi/structs"
+	"github.com/ukpabik/CSYou/pkg/economy"
+	"github.com/ukpabik/CSYou/pkg/shared"
+)
+
+// roundState accumulates a player's round until it is over.
+type roundState struct {
+	active     bool
+	partial    bool
+	round      int
+	team       string
+	startMoney int
+	lastMoney  int
+	spent      int
+	equipValue int
+	buyMoney   int // money at the end of freezetime
+	buyEquip   int // equipment value at the end of freezetime
+	buyLosses  int // team's losing streak at the end of freezetime
+	buySeen    bool
+	kills      int
+	headshots  int
+	lastHealth int
+	healthLost int
+	bomb       string
+
+	startedAt     int64
+	lastTimestamp int64
+	timeline      healthTimeline
+	flashed       exposure
+	smoked        exposure
+	burning       exposure
+	diedFlashed   bool
+}
+
+// exposure tracks time spent under a flash, smoke or burn effect.
+type exposure struct {
+	seconds float64
+	peak    int
+	last    int
+}
+
+// update adds the time since the last payload if the effect was active then.
+func (e *exposure) update(intensity int, elapsed float64) {
+	if e.last > 0 {
+		e.seconds += elapsed
+	}
+	e.peak = max(e.peak, intensity)
+	e.last = intensity
+}
+
+// healthTimeline holds the points at which a player's health or armor changed.
+type healthTimeline struct {
+	at     []int64
+	health []int
+	armor  []int
+}
+
+// record adds a point if health or armor changed since the last one.
+func (ht *healthTimeline) record(at int64, health, armor int) {
+	if n := len(ht.at); n > 0 && ht.health[n-1] == health && ht.armor[n-1] == armor {
+		return
+	}
+	ht.at = append(ht.at, at)
+	ht.health = append(ht.health, health)
+	ht.armor = append(ht.armor, armor)
+}
+
+// DetectRoundSummary follows the round phase (freezetime → live → over) and
+// returns the player's RoundSummary once the round is over, or nil. If the
+// over phase was missed, e.g. while spectating after death, the summary comes
+// when the next round starts instead.
+func (t *Tracker) DetectRoundSummary(matchID string, event *structs.GSIEvent) *shared.RoundSummary {
+	// Warmup rounds don't count
+	if event.CSMap.Phase == "warmup" {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state := t.player(event)
+	rs := &state.round
+	phase := event.Round.Phase
+	p := event.Player
+	money := p.State.Money
+	health := *p.State.Health
+
+	// The round counter moves on as the round ends, so a round only starts
+	// outside of the over phase
+	var summary *shared.RoundSummary
+	if phase != "over" && (!rs.active || rs.round != event.CSMap.Round) {
+		if rs.active && event.CSMap.Round > rs.round {
+			summary = rs.summary(matchID, event, state.utility.countsFor(rs.round))
+		}
+		*rs = roundState{
+			active:     true,
+			partial:    phase != "freezetime",
+			round:      event.CSMap.Round,
+			startMoney: money,
+			lastMoney:  money,
+			lastHealth: health,
+			startedAt:  int64(event.Provider.Timestamp),
+		}
+	}
+	if !rs.active {
+		return summary
+	}
+
+	// Money going down is buying, going back up in freezetime is a refund
+	switch {
+	case money < rs.lastMoney:
+		rs.spent += rs.lastMoney - money
+	case money > rs.lastMoney && phase == "freezetime":
+		rs.spent = max(rs.spent-(money-rs.lastMoney), 0)
+	}
+	rs.lastMoney = money
+
+	// The buy is judged at the end of freezetime, or on the first payload if
+	// freezetime was missed
+	if phase == "freezetime" || !rs.buySeen {
+		rs.buyMoney, rs.buyEquip, rs.buySeen = money, p.State.EquipValue, true
+		rs.buyLosses = teamLosses(event, p.Team)
+	}
+
+	prevHealth := rs.lastHealth
+	if health < rs.lastHealth {
+		rs.healthLost += rs.lastHealth - health
+	}
+	rs.lastHealth = health
+
+	// Exposure is timed with the provider clock, which replays consistently
+	timestamp := int64(event.Provider.Timestamp)
+	elapsed := 0.0
+	if rs.lastTimestamp > 0 && timestamp > rs.lastTimestamp {
+		elapsed = float64(timestamp - rs.lastTimestamp)
+	}
+	rs.lastTimestamp = timestamp
+
+	if health == 0 && prevHealth > 0 && (rs.flashed.last > 0 || p.State.Flashed > 0) {
+		rs.diedFlashed = true
+	}
+	// Effects only count while alive
+	flashed, smoked, burning := 0, 0, 0
+	if health > 0 {
+		flashed, smoked, burning = p.State.Flashed, p.State.Smoked, p.State.Burning
+	}
+	rs.flashed.update(flashed, elapsed)
+	rs.smoked.update(smoked, elapsed)
+	rs.burning.update(burning, elapsed)
+
+	rs.timeline.record(timestamp-rs.startedAt, health, *p.State.Armor)
+
+	if health > 0 {
+		rs.equipValue = max(rs.equipValue, p.State.EquipValue)
+	}
+	rs.team = p.Team
+	rs.kills = max(rs.kills, p.State.RoundKills)
+	rs.headshots = max(rs.headshots, p.State.RoundKillHS)
+	if event.Round.Bomb != "" {
+		rs.bomb = event.Round.Bomb
+	}
+
+	if phase != "over" {
+		return summary
+	}
+	rs.active = false
+
+	return rs.summary(matchID, event, state.utility.countsFor(rs.round))
+}
+
+// summary builds the RoundSummary for the round, taking the outcome from
+// event, which may already belong to a later round.
+func (rs *roundState) summary(matchID string, event *structs.GSIEvent, utility utilityCounts) *shared.RoundSummary {
+	winTeam, winCondition := roundWinner(event, rs.round)
+
+	// The round can end before the bomb state is seen
+	bomb := rs.bomb
+	switch winCondition {
+	case "t_win_bomb":
+		bomb = "exploded"
+	case "ct_win_defuse":
+		bomb = "defused"
+	}
+
+	forecast := rs.forecast(event.CSMap.Mode)
+
+	return &shared.RoundSummary{
+		MatchID:        matchID,
+		Round:          rs.round,
+		Map:            event.CSMap.Name,
+		Team:           rs.team,
+		SteamID:        event.Player.Steamid,
+		Name:           event.Player.Name,
+		Mode:           event.CSMap.Mode,
+		EventID:        shared.EventID(shared.EVENT_ROUND_SUMMARY, matchID, rs.round, event.Player.Steamid, nil),
+		StartMoney:     rs.startMoney,
+		Spent:          rs.spent,
+		EquipValue:     rs.equipValue,
+		BuyType:        forecast.BuyType,
+		Losses:         rs.buyLosses,
+		LossBonus:      forecast.LossBonus,
+		MinNextMoney:   forecast.MinNextMoney,
+		CanFullBuyNext: forecast.CanFullBuy,
+		BadBuy:         forecast.BadBuy,
+		Kills:          rs.kills,
+		Headshots:      rs.headshots,
+		HealthLost:     rs.healthLost,
+		Survived:       rs.lastHealth > 0,
+		UtilityBought:  utility.bought,
+		UtilityThrown:  utility.thrown,
+		UtilityLost:    utility.lost,
+		UtilitySpent:   utility.spent,
+		FlashedSeconds: rs.flashed.seconds,
+		PeakFlashed:    rs.flashed.peak,
+		SmokedSeconds:  rs.smoked.seconds,
+		PeakSmoked:     rs.smoked.peak,
+		BurningSeconds: rs.burning.seconds,
+		PeakBurning:    rs.burning.peak,
+		DiedFlashed:    rs.diedFlashed,
+		TimelineAt:     rs.timeline.at,
+		TimelineHealth: rs.timeline.health,
+		TimelineArmor:  rs.timeline.armor,
+		WinTeam:        winTeam,
+		WinCondition:   winCondition,
+		BombOutcome:    bomb,
+		Partial:        rs.partial,
+		Timestamp:      int64(event.Provider.Timestamp),
+	}
+}
+
+// roundWinner returns the team that won round and how, from event, which may
+// already belong to a later round. Both are "" if the round isn't decided.
+func roundWinner(event *structs.GSIEvent, round int) (string, string) {
+	winCondition := event.CSMap.RoundWins[strconv.Itoa(round+1)]
+
+	winTeam := ""
+	if event.Round.Phase == "over" && event.CSMap.Round == round+1 {
+		winTeam = event.Round.WinTeam
+	}
+	switch {
+	case winTeam != "":
+	case strings.HasPrefix(winCondition, "ct_"):
+		winTeam = "CT"
+	case strings.HasPrefix(winCondition, "t_"):
+		winTeam = "T"
+	}
+	return winTeam, winCondition
+}
+
+// forecast models the player's economy from their buy this round.
+func (rs *roundState) forecast(mode string) economy.Forecast {
+	return economy.ForecastRound(mode, rs.team, rs.round, rs.buyLosses, rs.startMoney, rs.buyMoney, rs.buyEquip)
+}
+
+// teamLosses returns the losing streak of the team, "CT" or "T".
+func teamLosses(event *structs.GSIEvent, team string) int {
+	t := event.CSMap.TeamCt
+	if team == "T" {
+		t = event.CSMap.TeamT
+	}
+	if t == nil {
+		return 0
+	}
+	return t.ConsecutiveRoundLosses
+}
@@ -0,0 +1,101 @@
+package player_events
+
+import (
+	"sync"
+
+	"github.com/LukeyR/CS2-GameStateIntegration/pkg/cs2gsi/structs"
+)
+
+// playerState is what the detectors remember about a player between
+// payloads.
+type playerState struct {
+	kills       int
+	roundKills  int
+	roundKillHS int
+
+	deaths     int
+	health     int
+	seenHealth bool
+	loadout    loadout
+
+	assists    int
+	flashbangs int
+	flashThrow int64
+	hasThrown  bool
+
+	round   roundState
+	bomb    bombState
+	utility utilityState
+	damage  damageState
+	economy economyState
+	clutch  clutchState
+	badges  badgeState
+	duel    duelState
+}
+
+// Tracker holds the kill, death and assist delta state for one match
+// session. It is safe for concurrent use.
+type Tracker struct {
+	mu           sync.Mutex
+	players      map[string]*playerState
+	deaths       map[int]*teamDeaths // by round, for opening duels and trades
+	baselineNext bool
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{
+		players: make(map[string]*playerState),
+		deaths:  make(map[int]*teamDeaths),
+	}
+}
+
+// Reset forgets every player, so counters are tracked from zero again. Use it
+// when the game resets stats: a new match, or warmup ending.
+func (t *Tracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.players = make(map[string]*playerState)
+	t.deaths = make(map[int]*teamDeaths)
+	t.baselineNext = false
+}
+
+// Rebaseline forgets every player and takes the counters of the next payload
+// for each as the starting point, without emitting events for them. Use it
+// when payloads were missed but the match carried on: a reconnect, or
+// joining a match in progress.
+func (t *Tracker) Rebaseline() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.players = make(map[string]*playerState)
+	t.deaths = make(map[int]*teamDeaths)
+	t.baselineNext = true
+}
+
+// player returns the state for the event's player, creating it on first
+// sight. Must be called with t.mu held.
+func (t *Tracker) player(event *structs.GSIEvent) *playerState {
+	steamid := event.Player.Steamid
+	if state, ok := t.players[steamid]; ok {
+		return state
+	}
+
+	state := &playerState{}
+	if t.baselineNext {
+		p := event.Player
+		state.kills = p.MatchStats.Kills
+		state.roundKills = p.State.RoundKills
+		state.roundKillHS = p.State.RoundKillHS
+		state.deaths = p.MatchStats.Deaths
+		state.health = *p.State.Health
+		state.seenHealth = true
+		state.assists = p.MatchStats.Assists
+		state.flashbangs = grenadeCount(p, "weapon_flashbang")
+		state.utility.held = utilityHeld(p)
+		state.utility.lastMoney = p.State.Money
+	}
+	t.players[steamid] = state
+
+	return state
+}
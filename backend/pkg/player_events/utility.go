@@ -0,0 +1,169 @@
+package player_events
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/LukeyR/CS2-GameStateIntegration/pkg/cs2gsi/structs"
+	"github.com/ukpabik/CSYou/pkg/shared"
+)
+
+// UTILITY_PRICES are the buy menu prices of each grenade.
+var UTILITY_PRICES = map[string]int{
+	"weapon_smokegrenade": 300,
+	"weapon_flashbang":    200,
+	"weapon_hegrenade":    300,
+	"weapon_molotov":      400,
+	"weapon_incgrenade":   500,
+	"weapon_decoy":        50,
+}
+
+// utilityCounts tallies a player's utility over one round.
+type utilityCounts struct {
+	round  int
+	bought int
+	thrown int
+	lost   int
+	spent  int
+}
+
+// utilityState is a player's grenade inventory as last seen, and the tallies
+// for the current and previous round.
+type utilityState struct {
+	held      map[string]int
+	lastMoney int
+	current   utilityCounts
+	previous  utilityCounts
+}
+
+// countsFor returns the tallies for round, or empty ones if it is neither
+// the current nor the previous round.
+func (us *utilityState) countsFor(round int) utilityCounts {
+	switch round {
+	case us.current.round:
+		return us.current
+	case us.previous.round:
+		return us.previous
+	}
+	return utilityCounts{round: round}
+}
+
+// DetectUtilityEvents diffs the player's grenades against the last payload
+// and emits a UtilityEvent for each grenade bought, picked up, thrown or lost
+// on death.
+func (t *Tracker) DetectUtilityEvents(matchID string, event *structs.GSIEvent) []*shared.UtilityEvent {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state := t.player(event)
+	us := &state.utility
+	p := event.Player
+	held := utilityHeld(p)
+	money := p.State.Money
+	alive := *p.State.Health > 0
+	frozen := inFreezetime(event)
+
+	prevHeld, prevMoney := us.held, us.lastMoney
+	us.held, us.lastMoney = held, money
+
+	// Warmup utility is free and doesn't count
+	if event.CSMap.Phase == "warmup" {
+		return nil
+	}
+
+	round := roundOf(event)
+	if round != us.current.round {
+		us.previous = us.current
+		us.current = utilityCounts{round: round}
+	}
+
+	gained := make(map[string]int)
+	gainedValue := 0
+	var utilityEvents []*shared.UtilityEvent
+	newEvent := func(grenade, action string, count int) *shared.UtilityEvent {
+		return &shared.UtilityEvent{
+			MatchID:   matchID,
+			Round:     round,
+			Map:       event.CSMap.Name,
+			Team:      p.Team,
+			SteamID:   p.Steamid,
+			Name:      p.Name,
+			Mode:      event.CSMap.Mode,
+			EventID:   shared.EventID(shared.EVENT_UTILITY, matchID, round, p.Steamid, fmt.Sprintf("%s:%s:%d", grenade, action, event.Provider.Timestamp)),
+			Grenade:   grenade,
+			Action:    action,
+			Count:     count,
+			Timestamp: int64(event.Provider.Timestamp),
+		}
+	}
+
+	grenades := slices.Sorted(maps.Keys(UTILITY_PRICES))
+	for _, grenade := range grenades {
+		price := UTILITY_PRICES[grenade]
+		delta := held[grenade] - prevHeld[grenade]
+		switch {
+		case delta > 0:
+			gained[grenade] = delta
+			gainedValue += delta * price
+		case delta < 0 && alive && frozen:
+			// Nothing can be thrown before the round starts, so it was sold
+			// back or dropped for a teammate
+		case delta < 0 && alive:
+			us.current.thrown += -delta
+			utilityEvents = append(utilityEvents, newEvent(grenade, shared.UTILITY_THROWN, -delta))
+		case delta < 0:
+			// Everything still held is dropped on death
+			us.current.lost += -delta
+			utilityEvents = append(utilityEvents, newEvent(grenade, shared.UTILITY_LOST, -delta))
+		}
+	}
+
+	// Grenades only count as bought if the money went down by at least their
+	// price, otherwise they came off the ground
+	bought := prevMoney-money >= gainedValue
+	for _, grenade := range grenades {
+		count := gained[grenade]
+		if count == 0 {
+			continue
+		}
+		if !bought {
+			utilityEvents = append(utilityEvents, newEvent(grenade, shared.UTILITY_PICKED_UP, count))
+			continue
+		}
+
+		ue := newEvent(grenade, shared.UTILITY_BOUGHT, count)
+		ue.Value = count * UTILITY_PRICES[grenade]
+		us.current.bought += count
+		us.current.spent += ue.Value
+		utilityEvents = append(utilityEvents, ue)
+	}
+
+	return utilityEvents
+}
+
+// utilityHeld counts the grenades the player holds, by weapon name.
+func utilityHeld(player *structs.Player) map[string]int {
+	held := make(map[string]int)
+	for grenade := range UTILITY_PRICES {
+		if count := grenadeCount(player, grenade); count > 0 {
+			held[grenade] = count
+		}
+	}
+	return held
+}
+
+// inFreezetime reports whether the round has yet to start, when grenades can
+// be sold back or dropped but not thrown.
+func inFreezetime(event *structs.GSIEvent) bool {
+	return event.Round != nil && event.Round.Phase == "freezetime"
+}
+
+// roundOf returns the round a payload belongs to. The round counter moves on
+// as soon as a round is over.
+func roundOf(event *structs.GSIEvent) int {
+	if event.Round.Phase == "over" {
+		return event.CSMap.Round - 1
+	}
+	return event.CSMap.Round
+}
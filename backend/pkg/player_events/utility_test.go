@@ -0,0 +1,70 @@
+package player_events
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/LukeyR/CS2-GameStateIntegration/pkg/cs2gsi/structs"
+	"github.com/ukpabik/CSYou/pkg/shared"
+)
+
+// utilityPayload is a payload for a live player holding smokes smoke grenades.
+func utilityPayload(t *testing.T, phase string, money, smokes int) *structs.GSIEvent {
+	t.Helper()
+
+	weapons := `{"weapon_0": {"name": "weapon_knife", "type": "Knife"}}`
+	if smokes > 0 {
+		weapons = fmt.Sprintf(`{
+			"weapon_0": {"name": "weapon_knife", "type": "Knife"},
+			"weapon_1": {"name": "weapon_smokegrenade", "type": "Grenade", "ammo_reserve": %d}
+		}`, smokes)
+	}
+
+	event, err := structs.NewGSIEvent(fmt.Sprintf(`{
+		"provider": {"steamid": "76561198000000001", "timestamp": 1700000000},
+		"map": {"name": "de_mirage", "mode": "competitive", "phase": "live", "round": 3},
+		"round": {"phase": %q},
+		"player": {
+			"steamid": "76561198000000001",
+			"name": "player",
+			"team": "CT",
+			"state": {"health": 100, "money": %d},
+			"weapons": %s
+		}
+	}`, phase, money, weapons))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+func TestSellBackIsNotThrown(t *testing.T) {
+	tracker := NewTracker()
+	tracker.DetectUtilityEvents("match", utilityPayload(t, "freezetime", 3000, 0))
+
+	bought := tracker.DetectUtilityEvents("match", utilityPayload(t, "freezetime", 2700, 1))
+	if len(bought) != 1 || bought[0].Action != shared.UTILITY_BOUGHT {
+		t.Fatalf("expected one bought event, got %+v", bought)
+	}
+
+	sold := tracker.DetectUtilityEvents("match", utilityPayload(t, "freezetime", 3000, 0))
+	for _, ue := range sold {
+		if ue.Action == shared.UTILITY_THROWN {
+			t.Fatalf("sell-back counted as thrown: %+v", ue)
+		}
+	}
+	if thrown := tracker.players["76561198000000001"].utility.current.thrown; thrown != 0 {
+		t.Fatalf("expected no grenades thrown, got %d", thrown)
+	}
+}
+
+func TestLiveDropIsThrown(t *testing.T) {
+	tracker := NewTracker()
+	tracker.DetectUtilityEvents("match", utilityPayload(t, "freezetime", 3000, 0))
+	tracker.DetectUtilityEvents("match", utilityPayload(t, "freezetime", 2700, 1))
+
+	thrown := tracker.DetectUtilityEvents("match", utilityPayload(t, "live", 2700, 0))
+	if len(thrown) != 1 || thrown[0].Action != shared.UTILITY_THROWN || thrown[0].Count != 1 {
+		t.Fatalf("expected one thrown event, got %+v", thrown)
+	}
+}
@@ -1,6 +1,11 @@
 package redis
 
-import "github.com/redis/go-redis/v9"
+import (
+	"context"
+	"log"
+
+	"github.com/redis/go-redis/v9"
+)
 
 var RedisClient *redis.Client
 
@@ -12,5 +17,13 @@ func InitializeRedisClient(addr string) {
 		Protocol: 2,  // Using the connection Protocol
 	})
 
+	// Test the connection, caching is skipped without one
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		log.Printf("unable to ping Redis, running without the cache: %v", err)
+		client.Close()
+		return
+	}
+
+	log.Println("Connected to Redis client")
 	RedisClient = client
 }
@@ -4,35 +4,74 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 
 	"github.com/ukpabik/CSYou/pkg/shared"
 )
 
-// HandlePlayerEvent parses the game event and stores it in Redis.
-func HandlePlayerEvent(event *shared.RedisPlayerEvent) {
-	if event == nil {
-		return
+// HandlePlayerEvent parses the game event and stores it in Redis. Storing
+// the same event again overwrites it, so redelivered events are harmless.
+func HandlePlayerEvent(event *shared.RedisPlayerEvent) error {
+	if event == nil || RedisClient == nil {
+		return nil
 	}
+
 	ctx := context.Background()
-	err := storePlayerEvent(ctx, event)
-	if err != nil {
-		log.Printf("failed to store player event: %v", err)
+	if err := storePlayerEvent(ctx, event); err != nil {
+		return fmt.Errorf("failed to store player event: %w", err)
 	}
-
+	return nil
 }
 
 // HandleKillEvent is the public function to process a kill event and store it.
-func HandleKillEvent(event *shared.RedisKillEvent) {
-	if event == nil {
-		return
+func HandleKillEvent(event *shared.RedisKillEvent) error {
+	if event == nil || RedisClient == nil {
+		return nil
 	}
 
 	ctx := context.Background()
-	err := storeKillEvent(ctx, event)
-	if err != nil {
-		log.Printf("failed to store kill event: %v", err)
+	if err := storeKillEvent(ctx, event); err != nil {
+		return fmt.Errorf("failed to store kill event: %w", err)
+	}
+	return nil
+}
+
+// HandleDeathEvent processes a death event and stores it.
+func HandleDeathEvent(event *shared.RedisDeathEvent) error {
+	if event == nil || RedisClient == nil {
+		return nil
+	}
+
+	ctx := context.Background()
+	if err := storeDeathEvent(ctx, event); err != nil {
+		return fmt.Errorf("failed to store death event: %w", err)
+	}
+	return nil
+}
+
+// HandleAssistEvent processes an assist event and stores it.
+func HandleAssistEvent(event *shared.RedisAssistEvent) error {
+	if event == nil || RedisClient == nil {
+		return nil
+	}
+
+	ctx := context.Background()
+	if err := storeAssistEvent(ctx, event); err != nil {
+		return fmt.Errorf("failed to store assist event: %w", err)
+	}
+	return nil
+}
+
+// HandleRoundSummary processes a round summary and stores it.
+func HandleRoundSummary(summary *shared.RoundSummary) error {
+	if summary == nil || RedisClient == nil {
+		return nil
+	}
+
+	ctx := context.Background()
+	if err := storeRoundSummary(ctx, summary); err != nil {
+		return fmt.Errorf("failed to store round summary: %w", err)
 	}
+	return nil
 }
 
 // storePlayerEvent is a helper function to store a player event into Redis.
@@ -68,12 +107,61 @@ func storeKillEvent(ctx context.Context, event *shared.RedisKillEvent) error {
 	return nil
 }
 
-func GetAllPlayerEvents(ctx context.Context) ([]RedisPlayerEvent, error) {
-	var playerEvents []RedisPlayerEvent
+func storeDeathEvent(ctx context.Context, event *shared.RedisDeathEvent) error {
+	if event == nil {
+		return fmt.Errorf("nil death event")
+	}
 
-	pattern := fmt.Sprintf("matches:*:round:*:player:%s:events", shared.PlayerID)
-	iter := RedisClient.Scan(ctx, 0, pattern, 0).Iterator()
+	key := fmt.Sprintf("matches:%s:round:%d:player:%s:deaths",
+		event.MatchID, event.Round, event.SteamID)
+
+	_, err := RedisClient.JSONSet(ctx, key, ".", event).Result()
+	if err != nil {
+		return fmt.Errorf("unable to add death event to Redis: %v", err)
+	}
+	return nil
+}
+
+func storeAssistEvent(ctx context.Context, event *shared.RedisAssistEvent) error {
+	if event == nil {
+		return fmt.Errorf("nil assist event")
+	}
+
+	key := fmt.Sprintf("matches:%s:round:%d:player:%s:assists",
+		event.MatchID, event.Round, event.SteamID)
 
+	_, err := RedisClient.JSONSet(ctx, key, ".", event).Result()
+	if err != nil {
+		return fmt.Errorf("unable to add assist event to Redis: %v", err)
+	}
+	return nil
+}
+
+// storeRoundSummary stores a round summary as a hash, so single fields can be
+// read without decoding the whole round.
+func storeRoundSummary(ctx context.Context, summary *shared.RoundSummary) error {
+	if summary == nil {
+		return fmt.Errorf("nil round summary")
+	}
+
+	key := fmt.Sprintf("matches:%s:round:%d:player:%s:summary",
+		summary.MatchID, summary.Round, summary.SteamID)
+
+	if err := RedisClient.HSet(ctx, key, summary).Err(); err != nil {
+		return fmt.Errorf("unable to add round summary to Redis: %v", err)
+	}
+	return nil
+}
+
+// getAllEvents returns every JSON value stored under keys matching pattern.
+func getAllEvents[T any](ctx context.Context, pattern string) ([]T, error) {
+	if RedisClient == nil {
+		return nil, fmt.Errorf("redis client is not initialized")
+	}
+
+	var events []T
+
+	iter := RedisClient.Scan(ctx, 0, pattern, 0).Iterator()
 	for iter.Next(ctx) {
 		val, err := RedisClient.JSONGet(ctx, iter.Val()).Result()
 		if err != nil {
@@ -82,48 +170,86 @@ func GetAllPlayerEvents(ctx context.Context) ([]RedisPlayerEvent, error) {
 		if val == "" {
 			continue
 		}
-		var ev RedisPlayerEvent
+		var ev T
 		if err := json.Unmarshal([]byte(val), &ev); err != nil {
 			return nil, fmt.Errorf("unmarshal failed for key %s: %w", iter.Val(), err)
 		}
-		playerEvents = append(playerEvents, ev)
+		events = append(events, ev)
 	}
 	if err := iter.Err(); err != nil {
 		return nil, err
 	}
 
-	return playerEvents, nil
+	return events, nil
 }
 
-func GetAllKillEvents(ctx context.Context) ([]RedisKillEvent, error) {
-	var killEvents []RedisKillEvent
+// GetAllPlayerEvents returns the cached player events for steamID, or for
+// every player if steamID is empty.
+func GetAllPlayerEvents(ctx context.Context, steamID string) ([]RedisPlayerEvent, error) {
+	pattern := fmt.Sprintf("matches:*:round:*:player:%s:events", playerPattern(steamID))
+	return getAllEvents[RedisPlayerEvent](ctx, pattern)
+}
 
-	pattern := fmt.Sprintf("matches:*:round:*:player:%s:kills", shared.PlayerID)
-	iter := RedisClient.Scan(ctx, 0, pattern, 0).Iterator()
+// GetAllKillEvents returns the cached kill events for steamID, or for every
+// player if steamID is empty.
+func GetAllKillEvents(ctx context.Context, steamID string) ([]RedisKillEvent, error) {
+	pattern := fmt.Sprintf("matches:*:round:*:player:%s:kills", playerPattern(steamID))
+	return getAllEvents[RedisKillEvent](ctx, pattern)
+}
+
+// GetAllDeathEvents returns the cached death events for steamID, or for every
+// player if steamID is empty.
+func GetAllDeathEvents(ctx context.Context, steamID string) ([]RedisDeathEvent, error) {
+	pattern := fmt.Sprintf("matches:*:round:*:player:%s:deaths", playerPattern(steamID))
+	return getAllEvents[RedisDeathEvent](ctx, pattern)
+}
 
+// GetAllAssistEvents returns the cached assist events for steamID, or for
+// every player if steamID is empty.
+func GetAllAssistEvents(ctx context.Context, steamID string) ([]RedisAssistEvent, error) {
+	pattern := fmt.Sprintf("matches:*:round:*:player:%s:assists", playerPattern(steamID))
+	return getAllEvents[RedisAssistEvent](ctx, pattern)
+}
+
+// GetAllRoundSummaries returns the cached round summaries for steamID, or for
+// every player if steamID is empty.
+func GetAllRoundSummaries(ctx context.Context, steamID string) ([]RoundSummary, error) {
+	if RedisClient == nil {
+		return nil, fmt.Errorf("redis client is not initialized")
+	}
+
+	var summaries []RoundSummary
+
+	pattern := fmt.Sprintf("matches:*:round:*:player:%s:summary", playerPattern(steamID))
+	iter := RedisClient.Scan(ctx, 0, pattern, 0).Iterator()
 	for iter.Next(ctx) {
-		val, err := RedisClient.JSONGet(ctx, iter.Val()).Result()
-		if err != nil {
-			return nil, fmt.Errorf("jsonget failed for key %s: %w", iter.Val(), err)
-		}
-		if val == "" {
-			continue
-		}
-		var ev RedisKillEvent
-		if err := json.Unmarshal([]byte(val), &ev); err != nil {
-			return nil, fmt.Errorf("unmarshal failed for key %s: %w", iter.Val(), err)
+		var summary RoundSummary
+		if err := RedisClient.HGetAll(ctx, iter.Val()).Scan(&summary); err != nil {
+			return nil, fmt.Errorf("hgetall failed for key %s: %w", iter.Val(), err)
 		}
-		killEvents = append(killEvents, ev)
+		summaries = append(summaries, summary)
 	}
 	if err := iter.Err(); err != nil {
 		return nil, err
 	}
 
-	return killEvents, nil
+	return summaries, nil
+}
+
+// playerPattern returns the key segment matching steamID, or any player.
+func playerPattern(steamID string) string {
+	if steamID == "" {
+		return "*"
+	}
+	return steamID
 }
 
 // ClearCache removes all keys related to the current player from Redis.
 func ClearCache(ctx context.Context) error {
+	if RedisClient == nil {
+		return fmt.Errorf("redis client is not initialized")
+	}
+
 	pattern := "matches:*"
 	iter := RedisClient.Scan(ctx, 0, pattern, 100).Iterator()
 
@@ -147,6 +273,10 @@ func ClearCache(ctx context.Context) error {
 
 // GetCacheSize returns the total memory usage of the Redis instance in bytes.
 func GetCacheSize(ctx context.Context) (int64, error) {
+	if RedisClient == nil {
+		return -1, fmt.Errorf("redis client is not initialized")
+	}
+
 	pattern := "matches:*"
 	cursor := uint64(0)
 	count := int64(0)
@@ -4,4 +4,7 @@ import "github.com/ukpabik/CSYou/pkg/shared"
 
 type RedisPlayerEvent = shared.RedisPlayerEvent
 type RedisKillEvent = shared.RedisKillEvent
+type RedisDeathEvent = shared.RedisDeathEvent
+type RedisAssistEvent = shared.RedisAssistEvent
+type RoundSummary = shared.RoundSummary
 type ActiveGun = shared.ActiveGun
@@ -0,0 +1,92 @@
+package shared
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Reasons a GSI payload can be rejected for.
+const (
+	AUTH_MISSING_TOKEN = "missing_token"
+	AUTH_INVALID_TOKEN = "invalid_token"
+
+	// The token is valid but bound to a different player's client.
+	AUTH_STEAMID_MISMATCH = "steamid_mismatch"
+)
+
+// AuthRejection aggregates rejected payloads from one client for one reason.
+//
+// A misconfigured client shows up as a steady count from a known SteamID with
+// a single token fingerprint; an attack tends to show unknown SteamIDs or many
+// different fingerprints.
+type AuthRejection struct {
+	Reason           string `json:"reason"`
+	ProviderSteamID  string `json:"provider_steamid"`  // steamid of the sending client
+	TokenFingerprint string `json:"token_fingerprint"` // short hash, never the token itself
+	Count            int64  `json:"count"`
+	FirstSeen        int64  `json:"first_seen"`
+	LastSeen         int64  `json:"last_seen"`
+}
+
+// AuthRejectionTracker counts rejected GSI payloads. Safe for concurrent use.
+type AuthRejectionTracker struct {
+	mu         sync.Mutex
+	total      int64
+	rejections map[string]*AuthRejection
+}
+
+// AuthRejections is the process-wide tracker used by the GSI collector.
+var AuthRejections = NewAuthRejectionTracker()
+
+func NewAuthRejectionTracker() *AuthRejectionTracker {
+	return &AuthRejectionTracker{rejections: make(map[string]*AuthRejection)}
+}
+
+// Record counts a rejected payload.
+func (t *AuthRejectionTracker) Record(reason, providerSteamID, token string) {
+	fingerprint := ""
+	if token != "" {
+		sum := sha256.Sum256([]byte(token))
+		fingerprint = hex.EncodeToString(sum[:4])
+	}
+
+	key := reason + "|" + providerSteamID + "|" + fingerprint
+	now := time.Now().Unix()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.total++
+	rejection, ok := t.rejections[key]
+	if !ok {
+		rejection = &AuthRejection{
+			Reason:           reason,
+			ProviderSteamID:  providerSteamID,
+			TokenFingerprint: fingerprint,
+			FirstSeen:        now,
+		}
+		t.rejections[key] = rejection
+	}
+	rejection.Count++
+	rejection.LastSeen = now
+}
+
+// Snapshot returns the total rejection count and a copy of every aggregate,
+// most recent first.
+func (t *AuthRejectionTracker) Snapshot() (int64, []AuthRejection) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	rejections := make([]AuthRejection, 0, len(t.rejections))
+	for _, r := range t.rejections {
+		rejections = append(rejections, *r)
+	}
+	sort.Slice(rejections, func(i, j int) bool {
+		return rejections[i].LastSeen > rejections[j].LastSeen
+	})
+
+	return t.total, rejections
+}
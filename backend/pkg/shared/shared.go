@@ -1,16 +1,15 @@
 package shared
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
 	"github.com/LukeyR/CS2-GameStateIntegration/pkg/cs2gsi/events"
 	"github.com/LukeyR/CS2-GameStateIntegration/pkg/cs2gsi/structs"
 )
 
-// Variables for current match information.
-var CurrentMatchID string
-var LastMap string
-var LastRound int
-var PlayerID string
-
 const (
 	REDIS_PORT      = 6379
 	CLICKHOUSE_PORT = 9000
@@ -20,6 +19,24 @@ const (
 	ADDRESS         = "localhost"
 )
 
+// Event types, as named in an EventEnvelope and passed to EventID
+const (
+	EVENT_PLAYER        = "player"
+	EVENT_KILL          = "kill"
+	EVENT_DEATH         = "death"
+	EVENT_ASSIST        = "assist"
+	EVENT_MATCH         = "match"
+	EVENT_ROUND_SUMMARY = "round_summary"
+	EVENT_BOMB          = "bomb"
+	EVENT_UTILITY       = "utility"
+	EVENT_DAMAGE        = "damage"
+	EVENT_CLUTCH        = "clutch"
+	EVENT_MULTI_KILL    = "multi_kill"
+	EVENT_BADGE         = "badge"
+	EVENT_DUEL          = "duel"
+	EVENT_DEAD_LETTER   = "dead_letter"
+)
+
 // Wrapper for Kafka event handling
 type EventWrapper struct {
 	GSIEvent    *structs.GSIEvent        `json:"gsi_event"`
@@ -28,13 +45,14 @@ type EventWrapper struct {
 }
 
 type RedisPlayerEvent struct {
-	MatchID string `json:"match_id"` // UUID you generate
+	MatchID string `json:"match_id"` // match it happened in
 	Round   int    `json:"round"`    // current round
 	Map     string `json:"map"`      // map name (e.g., de_dust2)
 	Team    string `json:"team"`     // "T" or "CT"
 	SteamID string `json:"steamid"`  // player steamid
 	Name    string `json:"name"`     // player name
 	Mode    string `json:"mode"`     // gamemode
+	EventID string `json:"event_id"` // see EventID
 
 	// Player state
 	Health     int  `json:"health"`
@@ -43,6 +61,11 @@ type RedisPlayerEvent struct {
 	Money      int  `json:"money"`
 	EquipValue int  `json:"equip_value"`
 
+	// Effect intensities, 0-255
+	Flashed int `json:"flashed"`
+	Smoked  int `json:"smoked"`
+	Burning int `json:"burning"`
+
 	// Per-round stats
 	RoundKills  int `json:"round_kills"`
 	RoundKillHS int `json:"round_killhs"`
@@ -60,19 +83,375 @@ type RedisPlayerEvent struct {
 }
 
 type RedisKillEvent struct {
-	MatchID string `json:"match_id"` // UUID you generate
+	MatchID string `json:"match_id"` // match it happened in
+	Round   int    `json:"round"`    // current round
+	Map     string `json:"map"`      // map name (e.g., de_dust2)
+	Team    string `json:"team"`     // "T" or "CT"
+	SteamID string `json:"steamid"`  // player steamid
+	Name    string `json:"name"`     // player name
+	Mode    string `json:"mode"`     // gamemode
+	EventID string `json:"event_id"` // see EventID
+
+	ActiveGun     ActiveGun `json:"active_gun"`     // weapon details
+	HeadshotExact bool      `json:"headshot_exact"` // false if the headshot flag had to be guessed
+
+	Timestamp int64 `json:"timestamp"` // provider timestamp
+}
+
+type RedisDeathEvent struct {
+	MatchID string `json:"match_id"` // match it happened in
+	Round   int    `json:"round"`    // current round
+	Map     string `json:"map"`      // map name (e.g., de_dust2)
+	Team    string `json:"team"`     // "T" or "CT"
+	SteamID string `json:"steamid"`  // player steamid
+	Name    string `json:"name"`     // player name
+	Mode    string `json:"mode"`     // gamemode
+	EventID string `json:"event_id"` // see EventID
+
+	RoundPhase  string  `json:"round_phase"`  // live, bomb or defuse
+	RoundClock  float64 `json:"round_clock"`  // seconds left on the round clock, -1 if unknown
+	WeaponName  string  `json:"weapon_name"`  // weapon held when dying
+	WeaponType  string  `json:"weapon_type"`  // Rifle, Pistol, Knife, C4
+	EquipValue  int     `json:"equip_value"`  // equipment value lost
+	BombPlanted bool    `json:"bomb_planted"` // bomb was down at time of death
+
+	Timestamp int64 `json:"timestamp"` // provider timestamp
+}
+
+type RedisAssistEvent struct {
+	MatchID string `json:"match_id"` // match it happened in
+	Round   int    `json:"round"`    // current round
+	Map     string `json:"map"`      // map name (e.g., de_dust2)
+	Team    string `json:"team"`     // "T" or "CT"
+	SteamID string `json:"steamid"`  // player steamid
+	Name    string `json:"name"`     // player name
+	Mode    string `json:"mode"`     // gamemode
+	EventID string `json:"event_id"` // see EventID
+
+	WeaponName  string `json:"weapon_name"`  // weapon held at time of assist
+	WeaponType  string `json:"weapon_type"`  // Rifle, Pistol, Knife, Grenade
+	FlashAssist bool   `json:"flash_assist"` // a flashbang was thrown just before
+
+	Timestamp int64 `json:"timestamp"` // provider timestamp
+}
+
+// RoundSummary is one player's round, emitted when the round is over. The
+// redis tags lay it out as a Redis hash.
+type RoundSummary struct {
+	MatchID string `json:"match_id" redis:"match_id"` // match it happened in
+	Round   int    `json:"round" redis:"round"`       // round as stamped on its events
+	Map     string `json:"map" redis:"map"`           // map name (e.g., de_dust2)
+	Team    string `json:"team" redis:"team"`         // "T" or "CT"
+	SteamID string `json:"steamid" redis:"steamid"`   // player steamid
+	Name    string `json:"name" redis:"name"`         // player name
+	Mode    string `json:"mode" redis:"mode"`         // gamemode
+	EventID string `json:"event_id" redis:"event_id"` // see EventID
+
+	// Economy
+	StartMoney int    `json:"start_money" redis:"start_money"` // money when the round started
+	Spent      int    `json:"spent" redis:"spent"`             // money spent, net of refunds
+	EquipValue int    `json:"equip_value" redis:"equip_value"` // highest equipment value while alive
+	BuyType    string `json:"buy_type" redis:"buy_type"`       // pistol, eco, force, half or full
+
+	// Economy forecast from the buy, see TeamEconomy
+	Losses         int    `json:"losses" redis:"losses"`
+	LossBonus      int    `json:"loss_bonus" redis:"loss_bonus"`
+	MinNextMoney   int    `json:"min_next_money" redis:"min_next_money"`
+	CanFullBuyNext bool   `json:"can_full_buy_next" redis:"can_full_buy_next"`
+	BadBuy         string `json:"bad_buy" redis:"bad_buy"`
+
+	// Performance
+	Kills      int  `json:"kills" redis:"kills"`
+	Headshots  int  `json:"headshots" redis:"headshots"`
+	HealthLost int  `json:"health_lost" redis:"health_lost"` // damage taken, as a proxy for damage
+	Survived   bool `json:"survived" redis:"survived"`
+
+	// Utility
+	UtilityBought int `json:"utility_bought" redis:"utility_bought"` // grenades bought
+	UtilityThrown int `json:"utility_thrown" redis:"utility_thrown"` // grenades thrown
+	UtilityLost   int `json:"utility_lost" redis:"utility_lost"`     // grenades still held on death
+	UtilitySpent  int `json:"utility_spent" redis:"utility_spent"`   // money spent on grenades
+
+	// Exposure, while alive. Intensities are 0-255
+	FlashedSeconds float64 `json:"flashed_seconds" redis:"flashed_seconds"`
+	PeakFlashed    int     `json:"peak_flashed" redis:"peak_flashed"`
+	SmokedSeconds  float64 `json:"smoked_seconds" redis:"smoked_seconds"`
+	PeakSmoked     int     `json:"peak_smoked" redis:"peak_smoked"`
+	BurningSeconds float64 `json:"burning_seconds" redis:"burning_seconds"`
+	PeakBurning    int     `json:"peak_burning" redis:"peak_burning"`
+	DiedFlashed    bool    `json:"died_flashed" redis:"died_flashed"` // died while flashed
+
+	// Health timeline, one point per change of health or armor. Not cached
+	TimelineAt     []int64 `json:"timeline_at" redis:"-"` // seconds since the round started
+	TimelineHealth []int   `json:"timeline_health" redis:"-"`
+	TimelineArmor  []int   `json:"timeline_armor" redis:"-"`
+
+	// Outcome
+	WinTeam      string `json:"win_team" redis:"win_team"`           // "T" or "CT"
+	WinCondition string `json:"win_condition" redis:"win_condition"` // round_wins reason, e.g. t_win_bomb
+	BombOutcome  string `json:"bomb_outcome" redis:"bomb_outcome"`   // planted, exploded, defused or ""
+
+	Partial   bool  `json:"partial" redis:"partial"`     // first seen after freezetime
+	Timestamp int64 `json:"timestamp" redis:"timestamp"` // provider timestamp
+}
+
+const (
+	BOMB_PICKED_UP = "picked_up"
+	BOMB_DROPPED   = "dropped"
+	BOMB_PLANTING  = "planting"
+	BOMB_PLANTED   = "planted"
+	BOMB_DEFUSING  = "defusing"
+	BOMB_DEFUSED   = "defused"
+	BOMB_EXPLODED  = "exploded"
+)
+
+type BombEvent struct {
+	MatchID string `json:"match_id"` // match it happened in
+	Round   int    `json:"round"`    // current round
+	Map     string `json:"map"`      // map name (e.g., de_dust2)
+	Team    string `json:"team"`     // "T" or "CT"
+	SteamID string `json:"steamid"`  // tracked player steamid
+	Name    string `json:"name"`     // tracked player name
+	Mode    string `json:"mode"`     // gamemode
+	EventID string `json:"event_id"` // see EventID
+
+	Action   string `json:"action"`    // picked_up, dropped, planting, planted, defusing, defused or exploded
+	Actor    string `json:"actor"`     // steamid of the carrier, planter or defuser, if known
+	ByPlayer bool   `json:"by_player"` // the tracked player is the actor
+	Site     string `json:"site"`      // empty, GSI doesn't say which bombsite
+	Position string `json:"position"`  // "x, y, z", only with the bomb block
+
+	Timestamp int64 `json:"timestamp"` // provider timestamp
+}
+
+const (
+	UTILITY_BOUGHT    = "bought"
+	UTILITY_PICKED_UP = "picked_up"
+	UTILITY_THROWN    = "thrown"
+	UTILITY_LOST      = "lost"
+)
+
+type UtilityEvent struct {
+	MatchID string `json:"match_id"` // match it happened in
+	Round   int    `json:"round"`    // round the utility belongs to
+	Map     string `json:"map"`      // map name (e.g., de_dust2)
+	Team    string `json:"team"`     // "T" or "CT"
+	SteamID string `json:"steamid"`  // player steamid
+	Name    string `json:"name"`     // player name
+	Mode    string `json:"mode"`     // gamemode
+	EventID string `json:"event_id"` // see EventID
+
+	Grenade string `json:"grenade"` // weapon_smokegrenade, weapon_flashbang, etc.
+	Action  string `json:"action"`  // bought, picked_up, thrown or lost
+	Count   int    `json:"count"`
+	Value   int    `json:"value"` // money spent, when bought
+
+	Timestamp int64 `json:"timestamp"` // provider timestamp
+}
+
+// MultiKillEvent is two or more kills by the player in one round, emitted
+// when the round closes.
+type MultiKillEvent struct {
+	MatchID string `json:"match_id"` // match it happened in
+	Round   int    `json:"round"`    // round of the kills
+	Map     string `json:"map"`      // map name (e.g., de_dust2)
+	Team    string `json:"team"`     // "T" or "CT"
+	SteamID string `json:"steamid"`  // player steamid
+	Name    string `json:"name"`     // player name
+	Mode    string `json:"mode"`     // gamemode
+	EventID string `json:"event_id"` // see EventID
+
+	Kills     int    `json:"kills"`
+	Headshots int    `json:"headshots"`
+	Label     string `json:"label"` // 2k, 3k, 4k or ace
+
+	Timestamp int64 `json:"timestamp"` // provider timestamp
+}
+
+const (
+	BADGE_ACE          = "ace"
+	BADGE_OPENING_KILL = "opening_kill" // first kill of the round
+	BADGE_ENTRY_DEATH  = "entry_death"  // first death of the round
+	BADGE_EXIT_FRAG    = "exit_frag"    // kill after the round was decided
+)
+
+// BadgeEvent is a badge the player earned in a round, emitted when the round
+// closes.
+type BadgeEvent struct {
+	MatchID string `json:"match_id"` // match it happened in
+	Round   int    `json:"round"`    // round the badge was earned in
+	Map     string `json:"map"`      // map name (e.g., de_dust2)
+	Team    string `json:"team"`     // "T" or "CT"
+	SteamID string `json:"steamid"`  // player steamid
+	Name    string `json:"name"`     // player name
+	Mode    string `json:"mode"`     // gamemode
+	EventID string `json:"event_id"` // see EventID
+
+	Badge string `json:"badge"` // ace, opening_kill, entry_death or exit_frag
+	Count int    `json:"count"` // times earned in the round, e.g. exit frags
+
+	Timestamp int64 `json:"timestamp"` // provider timestamp
+}
+
+const (
+	CLUTCH_STARTED = "started"
+	CLUTCH_KILL    = "kill"
+	CLUTCH_WON     = "won"
+	CLUTCH_LOST    = "lost"
+)
+
+// ClutchEvent is a step in a clutch: the player being left last alive on
+// their team, a kill they make, or the outcome.
+type ClutchEvent struct {
+	MatchID string `json:"match_id"` // match it happened in
+	Round   int    `json:"round"`    // round of the clutch
+	Map     string `json:"map"`      // map name (e.g., de_dust2)
+	Team    string `json:"team"`     // "T" or "CT"
+	SteamID string `json:"steamid"`  // player steamid
+	Name    string `json:"name"`     // player name
+	Mode    string `json:"mode"`     // gamemode
+	EventID string `json:"event_id"` // see EventID
+
+	Action  string `json:"action"`  // started, kill, won or lost
+	Vs      int    `json:"vs"`      // enemies alive when the clutch started
+	Enemies int    `json:"enemies"` // enemies alive now, -1 if unknown
+	Kills   int    `json:"kills"`   // kills made in the clutch so far
+
+	Timestamp int64 `json:"timestamp"` // provider timestamp
+}
+
+const (
+	DUEL_KILL  = "kill"
+	DUEL_DEATH = "death"
+)
+
+// DuelEvent is one of the player's kills or deaths, with whether it was the
+// round's opening duel and whether it was part of a trade.
+type DuelEvent struct {
+	MatchID string `json:"match_id"` // match it happened in
+	Round   int    `json:"round"`    // round of the kill or death
+	Map     string `json:"map"`      // map name (e.g., de_dust2)
+	Team    string `json:"team"`     // "T" or "CT"
+	SteamID string `json:"steamid"`  // player steamid
+	Name    string `json:"name"`     // player name
+	Mode    string `json:"mode"`     // gamemode
+	EventID string `json:"event_id"` // see EventID
+
+	Kind    string `json:"kind"`         // kill or death
+	Opening bool   `json:"opening"`      // first death of the round
+	Trade   bool   `json:"trade"`        // kill traded a teammate, or death was traded
+	Tracked bool   `json:"tracked"`      // alive counts were known, so Opening and Trade are meaningful
+	Window  int64  `json:"trade_window"` // trade window in seconds
+
+	Timestamp int64 `json:"timestamp"` // provider timestamp of the kill or death
+}
+
+// EventEnvelope wraps every event on the bus, saying what the payload is
+// and which version of its struct it was written with, so consumers can
+// read messages written by older releases.
+type EventEnvelope struct {
+	Type       string          `json:"type"`        // player, kill, ... see EVENT_PLAYER
+	Version    int             `json:"version"`     // schema version of the payload
+	EventID    string          `json:"event_id"`    // the payload's event ID, if it has one
+	ProducedAt int64           `json:"produced_at"` // unix milliseconds
+	SteamID    string          `json:"steamid"`     // steamid of the GSI client that sent it
+	Payload    json.RawMessage `json:"payload"`     // the event itself
+}
+
+// DeadLetter is a message a consumer gave up on, kept with why so it can be
+// inspected and re-driven.
+type DeadLetter struct {
+	ID       string `json:"id"`        // random, as a message can be dead-lettered more than once
+	Topic    string `json:"topic"`     // topic the message was consumed from
+	Group    string `json:"group"`     // consumer group that failed it
+	Key      string `json:"key"`       // original message key
+	Value    string `json:"value"`     // original message, as published
+	Offset   int64  `json:"offset"`    // position in the topic
+	Error    string `json:"error"`     // last error
+	Attempts int    `json:"attempts"`  // times it was tried
+	FailedAt int64  `json:"failed_at"` // unix milliseconds
+}
+
+// TeamEconomy is a player's economy for the round as it stands, sent live
+// while they buy.
+type TeamEconomy struct {
+	MatchID string `json:"match_id"` // match it happened in
 	Round   int    `json:"round"`    // current round
+	Team    string `json:"team"`     // "T" or "CT"
+	SteamID string `json:"steamid"`  // player steamid
+	Name    string `json:"name"`     // player name
+
+	Losses     int    `json:"losses"`     // team's losing streak
+	LossBonus  int    `json:"loss_bonus"` // income for losing this round
+	Money      int    `json:"money"`
+	EquipValue int    `json:"equip_value"`
+	BuyType    string `json:"buy_type"` // pistol, eco, force, half or full
+
+	MinNextMoney   int    `json:"min_next_money"` // money next round if this one is lost, kill rewards aside
+	CanFullBuyNext bool   `json:"can_full_buy_next"`
+	BadBuy         string `json:"bad_buy"` // after_pistol_loss, broke_economy or ""
+
+	Timestamp int64 `json:"timestamp"` // provider timestamp
+}
+
+// DamageEvent is health or armor the player lost in one payload.
+type DamageEvent struct {
+	MatchID string `json:"match_id"` // match it happened in
+	Round   int    `json:"round"`    // round the damage belongs to
 	Map     string `json:"map"`      // map name (e.g., de_dust2)
 	Team    string `json:"team"`     // "T" or "CT"
 	SteamID string `json:"steamid"`  // player steamid
 	Name    string `json:"name"`     // player name
 	Mode    string `json:"mode"`     // gamemode
+	EventID string `json:"event_id"` // see EventID
+
+	HealthDamage int  `json:"health_damage"`
+	ArmorDamage  int  `json:"armor_damage"`
+	Health       int  `json:"health"` // health left
+	Armor        int  `json:"armor"`  // armor left
+	Fatal        bool `json:"fatal"`
+
+	// A fight is a run of hits without a long enough break between them
+	FightStart  bool `json:"fight_start"`  // first hit of a fight
+	FightHealth int  `json:"fight_health"` // health going into the fight
+	FightHits   int  `json:"fight_hits"`   // hits taken in the fight so far
 
-	ActiveGun ActiveGun `json:"active_gun"` // weapon details
+	RoundPhase string  `json:"round_phase"` // live, bomb, defuse, ...
+	RoundClock float64 `json:"round_clock"` // seconds left in the phase, -1 if unknown
 
 	Timestamp int64 `json:"timestamp"` // provider timestamp
 }
 
+const (
+	MATCH_STARTED = "match_started"
+	MATCH_ENDED   = "match_ended"
+)
+
+type MatchEvent struct {
+	Type    string `json:"type"`     // match_started or match_ended
+	MatchID string `json:"match_id"` // identifies the match, shared by all its events
+	Map     string `json:"map"`      // map name (e.g., de_dust2)
+	Mode    string `json:"mode"`     // gamemode
+	SteamID string `json:"steamid"`  // steamid of the GSI client
+	Team    string `json:"team"`     // client's team at the time, "" when spectating
+	State   string `json:"state"`    // warmup, live, halftime, overtime or gameover
+	EventID string `json:"event_id"` // see EventID
+
+	JoinedInProgress bool `json:"joined_in_progress"` // first payload was mid-match
+
+	// Set on match_ended
+	ScoreCT  int    `json:"score_ct"`
+	ScoreT   int    `json:"score_t"`
+	Rounds   int    `json:"rounds"`
+	Winner   string `json:"winner"`   // "T", "CT" or "" on a tie
+	Result   string `json:"result"`   // win, loss, tie or abandoned, "" when spectating
+	Overtime bool   `json:"overtime"` // went past regulation
+
+	StartedAt int64 `json:"started_at"` // unix seconds
+	EndedAt   int64 `json:"ended_at"`   // unix seconds, 0 until ended
+	Duration  int64 `json:"duration"`   // seconds
+}
+
 type ActiveGun struct {
 	Name     string `json:"name"`     // weapon_ak47, weapon_glock, etc.
 	Type     string `json:"type"`     // Rifle, Pistol, Knife, C4
@@ -82,12 +461,12 @@ type ActiveGun struct {
 	Headshot bool   `json:"headshot"` // true if the kill was HS
 }
 
-// BundleEvent maps an event to Redis JSON structure.
-func BundlePlayerEvent(event *structs.GSIEvent, _ *events.GameEventDetails) *RedisPlayerEvent {
+// BundlePlayerEvent maps an event to Redis JSON structure.
+func BundlePlayerEvent(matchID string, event *structs.GSIEvent) *RedisPlayerEvent {
 
 	redisEvent := &RedisPlayerEvent{
 		// Match Information
-		MatchID: CurrentMatchID,
+		MatchID: matchID,
 		Round:   event.CSMap.Round,
 		Map:     event.CSMap.Name,
 		Team:    event.Player.Team,
@@ -101,6 +480,9 @@ func BundlePlayerEvent(event *structs.GSIEvent, _ *events.GameEventDetails) *Red
 		Helmet:     event.Player.State.Helmet,
 		Money:      event.Player.State.Money,
 		EquipValue: event.Player.State.EquipValue,
+		Flashed:    event.Player.State.Flashed,
+		Smoked:     event.Player.State.Smoked,
+		Burning:    event.Player.State.Burning,
 
 		// Per-round stats
 		RoundKills:  event.Player.State.RoundKills,
@@ -118,5 +500,17 @@ func BundlePlayerEvent(event *structs.GSIEvent, _ *events.GameEventDetails) *Red
 		WinTeam: event.Round.WinTeam,
 	}
 
+	// A snapshot is identified by everything in it
+	redisEvent.EventID = EventID(EVENT_PLAYER, matchID, redisEvent.Round, redisEvent.SteamID, fmt.Sprintf("%+v", *redisEvent))
+
 	return redisEvent
 }
+
+// EventID derives an event's ID from what identifies it: its kind, match,
+// round, player and seq, which tells apart events that otherwise share
+// those, such as a player's kill count. The same event detected or delivered
+// twice gets the same ID, so the stores can drop the copy.
+func EventID(kind, matchID string, round int, steamID string, seq any) string {
+	sum := sha256.Sum256(fmt.Appendf(nil, "%s|%s|%d|%s|%v", kind, matchID, round, steamID, seq))
+	return hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,911 @@
+package simulator
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+)
+
+// Phase lengths, as on official competitive servers.
+const (
+	warmupTime    = 10 * time.Second
+	freezeTime    = 15 * time.Second
+	roundTime     = 115 * time.Second
+	bombTime      = 40 * time.Second
+	plantTime     = 3 * time.Second
+	defuseTime    = 10 * time.Second
+	roundOverTime = 7 * time.Second
+	halftimeTime  = 15 * time.Second
+	gameoverTime  = 5 * time.Second
+	deathCamTime  = 3 * time.Second
+
+	startMoney = 800
+	maxMoney   = 16000
+	killReward = 300
+	winReward  = 3250
+)
+
+var playerNames = []string{"Alpha", "Bravo", "Charlie", "Delta", "Echo", "Foxtrot", "Golf", "Hotel", "India", "Juliett"}
+
+var bombSites = map[string]string{
+	"A": "-377.41, -2056.27, -175.53",
+	"B": "-2176.17, 394.65, -159.97",
+}
+
+// weaponSpec describes a weapon as GSI reports it.
+type weaponSpec struct {
+	kind    string
+	clipMax int
+	reserve int
+	price   int
+}
+
+var weaponCatalog = map[string]weaponSpec{
+	"weapon_knife":         {kind: "Knife"},
+	"weapon_knife_t":       {kind: "Knife"},
+	"weapon_glock":         {kind: "Pistol", clipMax: 20, reserve: 120, price: 200},
+	"weapon_usp_silencer":  {kind: "Pistol", clipMax: 12, reserve: 24, price: 200},
+	"weapon_deagle":        {kind: "Pistol", clipMax: 7, reserve: 35, price: 700},
+	"weapon_galilar":       {kind: "Rifle", clipMax: 35, reserve: 90, price: 1800},
+	"weapon_famas":         {kind: "Rifle", clipMax: 25, reserve: 90, price: 2050},
+	"weapon_ak47":          {kind: "Rifle", clipMax: 30, reserve: 90, price: 2700},
+	"weapon_m4a1_silencer": {kind: "Rifle", clipMax: 20, reserve: 80, price: 2900},
+	"weapon_awp":           {kind: "SniperRifle", clipMax: 5, reserve: 30, price: 4750},
+	"weapon_flashbang":     {kind: "Grenade", price: 200},
+	"weapon_smokegrenade":  {kind: "Grenade", price: 300},
+	"weapon_hegrenade":     {kind: "Grenade", price: 300},
+	"weapon_molotov":       {kind: "Grenade", price: 400},
+	"weapon_incgrenade":    {kind: "Grenade", price: 500},
+	"weapon_decoy":         {kind: "Grenade", price: 50},
+	"weapon_c4":            {kind: "C4"},
+}
+
+type weapon struct {
+	name    string
+	clip    int
+	reserve int // for grenades, how many are held
+	active  bool
+}
+
+type player struct {
+	steamID string
+	name    string
+	team    string
+	slot    int
+
+	health  int
+	armor   int
+	helmet  bool
+	money   int
+	flashed int
+	burning int
+
+	roundKills  int
+	roundKillHS int
+	stats       gsiMatchStats
+	diedAt      time.Time
+	bought      bool
+
+	weapons []*weapon
+}
+
+func (p *player) alive() bool { return p.health > 0 }
+
+type bomb struct {
+	state   string // carried, dropped, planting, planted, defusing, defused, exploded
+	carrier string
+	site    string
+	timer   time.Duration
+	action  time.Duration // time left on the current plant or defuse
+}
+
+// match is the simulated game state. step advances it by one tick.
+type match struct {
+	opts  Options
+	rng   *rand.Rand
+	clock time.Time
+
+	players []*player
+	watched *player // whose perspective the player block shows
+
+	mapPhase   string
+	roundPhase string
+	phaseLeft  time.Duration
+	liveFor    time.Duration
+
+	round     int // completed rounds
+	scores    map[string]int
+	losses    map[string]int
+	roundWins map[string]string
+	winTeam   string
+
+	bomb      *bomb
+	roundBomb string
+
+	done bool
+}
+
+func newMatch(opts Options, rng *rand.Rand) *match {
+	m := &match{
+		opts:      opts,
+		rng:       rng,
+		clock:     time.Now(),
+		mapPhase:  "warmup",
+		phaseLeft: warmupTime,
+		scores:    map[string]int{"CT": 0, "T": 0},
+		losses:    map[string]int{"CT": 0, "T": 0},
+		roundWins: make(map[string]string),
+	}
+
+	base, err := strconv.ParseUint(opts.SteamID, 10, 64)
+	if err != nil {
+		base = 76561198000000001
+	}
+	for i := 0; i < opts.Players; i++ {
+		team := "CT"
+		if i%2 == 1 {
+			team = "T"
+		}
+		p := &player{
+			steamID: strconv.FormatUint(base+uint64(i), 10),
+			name:    playerNames[i],
+			team:    team,
+			slot:    (i + 1) % 10,
+			health:  100,
+			money:   startMoney,
+		}
+		p.weapons = defaultLoadout(team)
+		m.players = append(m.players, p)
+	}
+	m.watched = m.players[0]
+	m.roundPhase = "freezetime"
+
+	return m
+}
+
+func defaultLoadout(team string) []*weapon {
+	if team == "T" {
+		return []*weapon{{name: "weapon_knife_t"}, newWeapon("weapon_glock")}
+	}
+	return []*weapon{{name: "weapon_knife"}, newWeapon("weapon_usp_silencer")}
+}
+
+func newWeapon(name string) *weapon {
+	spec := weaponCatalog[name]
+	w := &weapon{name: name, clip: spec.clipMax, reserve: spec.reserve}
+	if spec.kind == "Grenade" {
+		w.reserve = 1
+	}
+	return w
+}
+
+func (m *match) finished() bool { return m.done }
+
+func (m *match) tickSeconds() float64 { return m.opts.Tick.Seconds() }
+
+func (m *match) chance(p float64) bool { return m.rng.Float64() < p }
+
+// step advances the match by one tick.
+func (m *match) step() {
+	m.clock = m.clock.Add(m.opts.Tick)
+	m.phaseLeft -= m.opts.Tick
+	m.decayEffects()
+
+	switch m.mapPhase {
+	case "warmup", "intermission":
+		if m.phaseLeft <= 0 {
+			m.mapPhase = "live"
+			m.startRound()
+		}
+		return
+	case "gameover":
+		if m.phaseLeft <= 0 {
+			m.done = true
+		}
+		return
+	}
+
+	switch m.roundPhase {
+	case "freezetime":
+		// Players buy at their own pace, all before the round goes live
+		for _, p := range m.players {
+			if !p.bought && (m.phaseLeft <= 0 || m.chance(0.3)) {
+				m.buy(p)
+				p.bought = true
+				if p == m.players[0] {
+					m.setActive(p, p.bestGun())
+				}
+			}
+		}
+		if m.phaseLeft <= 0 {
+			m.roundPhase = "live"
+			m.phaseLeft = roundTime
+			m.liveFor = 0
+		}
+	case "live":
+		m.liveFor += m.opts.Tick
+		m.simulateLive()
+	case "over":
+		if m.phaseLeft <= 0 {
+			m.nextRound()
+		}
+	}
+
+	m.updateWatched()
+}
+
+func (m *match) decayEffects() {
+	flashDecay := int(255 * m.tickSeconds() / 3)
+	for _, p := range m.players {
+		p.flashed = max(0, p.flashed-flashDecay)
+		p.burning = max(0, p.burning-int(255*m.tickSeconds()/2))
+	}
+}
+
+// startRound resets per-round state and opens the buy phase.
+func (m *match) startRound() {
+	m.roundPhase = "freezetime"
+	m.phaseLeft = freezeTime
+	m.winTeam = ""
+	m.roundBomb = ""
+
+	var ts []*player
+	for _, p := range m.players {
+		if !p.alive() || len(p.weapons) == 0 {
+			p.weapons = defaultLoadout(p.team)
+			p.armor = 0
+			p.helmet = false
+		}
+		p.health = 100
+		p.roundKills = 0
+		p.roundKillHS = 0
+		p.removeWeapon("weapon_c4")
+		p.bought = false
+		if p.team == "T" {
+			ts = append(ts, p)
+		}
+	}
+
+	m.bomb = nil
+	if len(ts) > 0 {
+		carrier := ts[m.rng.Intn(len(ts))]
+		carrier.weapons = append(carrier.weapons, &weapon{name: "weapon_c4"})
+		m.bomb = &bomb{state: "carried", carrier: carrier.steamID}
+	}
+
+	m.setActive(m.players[0], m.players[0].bestGun())
+}
+
+func (m *match) isPistolRound() bool {
+	return m.round == 0 || m.round == m.opts.Rounds/2
+}
+
+// buy spends a player's money the way a typical player would.
+func (m *match) buy(p *player) {
+	if !m.isPistolRound() && p.armor < 100 && p.money >= 1000+2000 {
+		p.money -= 1000
+		p.armor, p.helmet = 100, true
+	} else if p.armor < 100 && p.money >= 650+500 {
+		p.money -= 650
+		p.armor = 100
+	}
+
+	if !m.isPistolRound() && p.primary() == nil {
+		rifle, force := "weapon_m4a1_silencer", "weapon_famas"
+		if p.team == "T" {
+			rifle, force = "weapon_ak47", "weapon_galilar"
+		}
+		if m.chance(0.1) {
+			rifle = "weapon_awp"
+		}
+		switch {
+		case p.money >= weaponCatalog[rifle].price+1000:
+			p.buyWeapon(rifle)
+		case p.money >= weaponCatalog[force].price && m.chance(0.4):
+			p.buyWeapon(force)
+		case p.money >= 700 && m.chance(0.3):
+			p.removeWeapon(p.pistol())
+			p.buyWeapon("weapon_deagle")
+		}
+	}
+
+	grenades := []string{"weapon_flashbang", "weapon_smokegrenade", "weapon_hegrenade", "weapon_flashbang"}
+	if p.team == "T" {
+		grenades = append(grenades, "weapon_molotov")
+	} else {
+		grenades = append(grenades, "weapon_incgrenade")
+	}
+	for _, g := range grenades {
+		if g != "weapon_flashbang" && p.has(g) {
+			continue
+		}
+		if p.grenadeCount() >= 4 || p.money < weaponCatalog[g].price+400 || !m.chance(0.6) {
+			continue
+		}
+		p.buyWeapon(g)
+	}
+}
+
+func (p *player) buyWeapon(name string) {
+	p.money -= weaponCatalog[name].price
+	for _, w := range p.weapons {
+		if w.name == name && weaponCatalog[name].kind == "Grenade" {
+			w.reserve++
+			return
+		}
+	}
+	p.weapons = append(p.weapons, newWeapon(name))
+}
+
+func (p *player) has(name string) bool {
+	for _, w := range p.weapons {
+		if w.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *player) removeWeapon(name string) {
+	for i, w := range p.weapons {
+		if w.name == name {
+			p.weapons = append(p.weapons[:i], p.weapons[i+1:]...)
+			return
+		}
+	}
+}
+
+func (p *player) primary() *weapon {
+	for _, w := range p.weapons {
+		switch weaponCatalog[w.name].kind {
+		case "Rifle", "SniperRifle":
+			return w
+		}
+	}
+	return nil
+}
+
+func (p *player) pistol() string {
+	for _, w := range p.weapons {
+		if weaponCatalog[w.name].kind == "Pistol" {
+			return w.name
+		}
+	}
+	return ""
+}
+
+func (p *player) bestGun() *weapon {
+	if w := p.primary(); w != nil {
+		return w
+	}
+	for _, w := range p.weapons {
+		if weaponCatalog[w.name].kind == "Pistol" {
+			return w
+		}
+	}
+	if len(p.weapons) > 0 {
+		return p.weapons[0]
+	}
+	return nil
+}
+
+func (p *player) grenadeCount() int {
+	count := 0
+	for _, w := range p.weapons {
+		if weaponCatalog[w.name].kind == "Grenade" {
+			count += w.reserve
+		}
+	}
+	return count
+}
+
+func (p *player) equipValue() int {
+	value := 0
+	for _, w := range p.weapons {
+		value += weaponCatalog[w.name].price * max(1, grenadeMultiplier(w))
+	}
+	if p.armor > 0 {
+		value += 650
+		if p.helmet {
+			value += 350
+		}
+	}
+	return value
+}
+
+func grenadeMultiplier(w *weapon) int {
+	if weaponCatalog[w.name].kind == "Grenade" {
+		return w.reserve
+	}
+	return 1
+}
+
+func (m *match) setActive(p *player, active *weapon) {
+	for _, w := range p.weapons {
+		w.active = w == active
+	}
+}
+
+func (m *match) alive(team string) []*player {
+	var alive []*player
+	for _, p := range m.players {
+		if p.team == team && p.alive() {
+			alive = append(alive, p)
+		}
+	}
+	return alive
+}
+
+func (m *match) byID(steamID string) *player {
+	for _, p := range m.players {
+		if p.steamID == steamID {
+			return p
+		}
+	}
+	return nil
+}
+
+// simulateLive runs one tick of a live round: fights, utility, the bomb and
+// the round end conditions.
+func (m *match) simulateLive() {
+	if m.chance(m.opts.EventRate * m.tickSeconds()) {
+		switch roll := m.rng.Float64(); {
+		case roll < 0.6:
+			m.fight(true)
+		case roll < 0.8:
+			m.fight(false)
+		default:
+			m.throwUtility()
+		}
+	}
+
+	// Weapon switches for the client's own player
+	if me := m.players[0]; me.alive() && m.chance(0.05) && len(me.weapons) > 1 {
+		m.setActive(me, me.weapons[m.rng.Intn(len(me.weapons))])
+	}
+
+	m.simulateBomb()
+
+	cts, ts := m.alive("CT"), m.alive("T")
+	switch {
+	case m.roundBomb == "exploded":
+		m.endRound("T", "t_win_bomb")
+	case m.roundBomb == "defused":
+		m.endRound("CT", "ct_win_defuse")
+	case len(cts) == 0:
+		m.endRound("T", "t_win_elimination")
+	case len(ts) == 0 && m.roundBomb != "planted":
+		m.endRound("CT", "ct_win_elimination")
+	case m.phaseLeft <= 0 && m.roundBomb != "planted":
+		m.endRound("CT", "ct_win_time")
+	}
+}
+
+// fight has a random player shoot a random enemy, either killing them or
+// just doing damage.
+func (m *match) fight(lethal bool) {
+	attackerTeam := "CT"
+	if m.chance(0.5) {
+		attackerTeam = "T"
+	}
+	victimTeam := "T"
+	if attackerTeam == "T" {
+		victimTeam = "CT"
+	}
+
+	attackers, victims := m.alive(attackerTeam), m.alive(victimTeam)
+	if len(attackers) == 0 || len(victims) == 0 {
+		return
+	}
+	attacker := attackers[m.rng.Intn(len(attackers))]
+	victim := victims[m.rng.Intn(len(victims))]
+
+	gun := attacker.bestGun()
+	m.setActive(attacker, gun)
+	if gun != nil && gun.clip > 0 {
+		gun.clip = max(0, gun.clip-1-m.rng.Intn(5))
+		if gun.clip == 0 && gun.reserve > 0 {
+			reload := min(weaponCatalog[gun.name].clipMax, gun.reserve)
+			gun.clip, gun.reserve = reload, gun.reserve-reload
+		}
+	}
+
+	damage := 10 + m.rng.Intn(50)
+	if !lethal {
+		victim.health = max(1, victim.health-damage)
+		victim.armor = max(0, victim.armor-damage/2)
+		return
+	}
+
+	headshot := m.chance(0.45)
+	m.kill(attacker, victim, headshot)
+
+	// Someone who damaged the victim earlier may get an assist
+	if m.chance(0.3) {
+		for _, p := range m.alive(attackerTeam) {
+			if p != attacker {
+				p.stats.Assists++
+				p.stats.Score++
+				break
+			}
+		}
+	}
+}
+
+func (m *match) kill(attacker, victim *player, headshot bool) {
+	victim.health = 0
+	victim.armor = 0
+	victim.helmet = false
+	victim.flashed = 0
+	victim.burning = 0
+	victim.stats.Deaths++
+	victim.diedAt = m.clock
+
+	if m.bomb != nil && m.bomb.carrier == victim.steamID && m.bomb.state == "carried" {
+		m.bomb.state = "dropped"
+		m.bomb.carrier = ""
+	}
+	victim.weapons = nil
+
+	attacker.roundKills++
+	attacker.stats.Kills++
+	attacker.stats.Score += 2
+	attacker.money = min(maxMoney, attacker.money+killReward)
+	if headshot {
+		attacker.roundKillHS++
+	}
+}
+
+// throwUtility has a random player throw one of their grenades.
+func (m *match) throwUtility() {
+	var throwers []*player
+	for _, p := range m.players {
+		if p.alive() && p.grenadeCount() > 0 {
+			throwers = append(throwers, p)
+		}
+	}
+	if len(throwers) == 0 {
+		return
+	}
+	p := throwers[m.rng.Intn(len(throwers))]
+
+	var nade *weapon
+	for _, w := range p.weapons {
+		if weaponCatalog[w.name].kind == "Grenade" {
+			nade = w
+			break
+		}
+	}
+
+	nade.reserve--
+	if nade.reserve == 0 {
+		p.removeWeapon(nade.name)
+	}
+	// Players switch back to their gun after a throw
+	m.setActive(p, p.bestGun())
+
+	enemyTeam := "T"
+	if p.team == "T" {
+		enemyTeam = "CT"
+	}
+	enemies := m.alive(enemyTeam)
+	if len(enemies) == 0 {
+		return
+	}
+	target := enemies[m.rng.Intn(len(enemies))]
+
+	switch nade.name {
+	case "weapon_flashbang":
+		target.flashed = 255
+	case "weapon_molotov", "weapon_incgrenade":
+		target.burning = 255
+		target.health = max(1, target.health-8)
+	case "weapon_hegrenade":
+		target.health = max(1, target.health-20-m.rng.Intn(40))
+		target.armor = max(0, target.armor-10)
+	}
+}
+
+func (m *match) simulateBomb() {
+	if m.bomb == nil {
+		return
+	}
+	b := m.bomb
+
+	switch b.state {
+	case "dropped":
+		if ts := m.alive("T"); len(ts) > 0 && m.chance(0.1) {
+			carrier := ts[m.rng.Intn(len(ts))]
+			carrier.weapons = append(carrier.weapons, &weapon{name: "weapon_c4"})
+			b.state, b.carrier = "carried", carrier.steamID
+		}
+	case "carried":
+		if m.liveFor >= 20*time.Second && m.chance(0.01) {
+			b.state = "planting"
+			b.action = plantTime
+			b.site = "A"
+			if m.chance(0.5) {
+				b.site = "B"
+			}
+		}
+	case "planting":
+		carrier := m.byID(b.carrier)
+		if carrier == nil || !carrier.alive() {
+			b.state, b.carrier = "dropped", ""
+			return
+		}
+		b.action -= m.opts.Tick
+		if b.action <= 0 {
+			carrier.removeWeapon("weapon_c4")
+			m.setActive(carrier, carrier.bestGun())
+			b.state = "planted"
+			b.carrier = ""
+			b.timer = bombTime
+			m.roundBomb = "planted"
+			m.phaseLeft = bombTime
+		}
+	case "planted", "defusing":
+		b.timer -= m.opts.Tick
+		if b.timer <= 0 {
+			b.state, b.carrier = "exploded", ""
+			m.roundBomb = "exploded"
+			for _, p := range m.players {
+				if p.alive() && m.chance(0.2) {
+					p.health = 0
+					p.stats.Deaths++
+					p.diedAt = m.clock
+					p.weapons = nil
+				}
+			}
+			return
+		}
+
+		if b.state == "planted" {
+			cts := m.alive("CT")
+			if len(cts) > 0 && (len(m.alive("T")) == 0 || m.chance(0.015)) {
+				b.state = "defusing"
+				b.carrier = cts[m.rng.Intn(len(cts))].steamID
+				b.action = defuseTime
+			}
+			return
+		}
+
+		defuser := m.byID(b.carrier)
+		if ts := m.alive("T"); defuser != nil && len(ts) > 0 && m.chance(0.1) {
+			// Defusing in the open is risky
+			m.kill(ts[m.rng.Intn(len(ts))], defuser, m.chance(0.45))
+		}
+		if defuser == nil || !defuser.alive() {
+			b.state, b.carrier = "planted", ""
+			return
+		}
+		b.action -= m.opts.Tick
+		if b.action <= 0 && b.timer > 0 {
+			b.state = "defused"
+			m.roundBomb = "defused"
+		}
+	}
+}
+
+// endRound awards the round and the money for it.
+func (m *match) endRound(winner, reason string) {
+	loser := "T"
+	if winner == "T" {
+		loser = "CT"
+	}
+
+	m.roundPhase = "over"
+	m.phaseLeft = roundOverTime
+	m.winTeam = winner
+	m.round++
+	m.roundWins[strconv.Itoa(m.round)] = reason
+	m.scores[winner]++
+	m.losses[winner] = 0
+	m.losses[loser] = min(m.losses[loser]+1, 4)
+
+	lossBonus := 1400 + 500*(m.losses[loser]-1)
+	var mvp *player
+	for _, p := range m.players {
+		if p.team == winner {
+			p.money = min(maxMoney, p.money+winReward)
+			if mvp == nil || p.roundKills > mvp.roundKills {
+				mvp = p
+			}
+		} else {
+			p.money = min(maxMoney, p.money+lossBonus)
+		}
+	}
+	if mvp != nil {
+		mvp.stats.MVPs++
+		mvp.stats.Score += 2
+	}
+}
+
+// nextRound moves on after a round ends: to the next round, halftime or the
+// end of the match.
+func (m *match) nextRound() {
+	half := m.opts.Rounds / 2
+	if m.scores["CT"] > half || m.scores["T"] > half || m.round >= m.opts.Rounds {
+		m.mapPhase = "gameover"
+		m.phaseLeft = gameoverTime
+		return
+	}
+
+	if m.round == half {
+		m.mapPhase = "intermission"
+		m.phaseLeft = halftimeTime
+		m.scores["CT"], m.scores["T"] = m.scores["T"], m.scores["CT"]
+		m.losses["CT"], m.losses["T"] = 0, 0
+		for _, p := range m.players {
+			if p.team == "CT" {
+				p.team = "T"
+			} else {
+				p.team = "CT"
+			}
+			p.money = startMoney
+			p.armor, p.helmet = 0, false
+			p.weapons = nil // startRound hands out the new side's loadout
+		}
+		return
+	}
+
+	m.startRound()
+}
+
+// updateWatched follows the player block the way the game does: the own
+// player while alive, a teammate after the death cam.
+func (m *match) updateWatched() {
+	me := m.players[0]
+	if me.alive() || m.clock.Sub(me.diedAt) < deathCamTime {
+		m.watched = me
+		return
+	}
+	if m.watched != me && m.watched.alive() && m.watched.team == me.team {
+		return
+	}
+	m.watched = me
+	for _, p := range m.alive(me.team) {
+		m.watched = p
+		break
+	}
+}
+
+func (m *match) phaseCountdown() gsiPhaseCountdowns {
+	phase := m.roundPhase
+	switch {
+	case m.mapPhase != "live":
+		phase = m.mapPhase
+	case m.bomb != nil && m.bomb.state == "defusing":
+		phase = "defuse"
+	case m.roundBomb == "planted":
+		phase = "bomb"
+	}
+	return gsiPhaseCountdowns{
+		Phase:       phase,
+		PhaseEndsIn: fmt.Sprintf("%.1f", max(0, m.phaseLeft.Seconds())),
+	}
+}
+
+func (m *match) gsiPlayer(p *player, withSteamID bool) *gsiPlayer {
+	out := &gsiPlayer{
+		Name:         p.name,
+		ObserverSlot: p.slot,
+		Team:         p.team,
+		MatchStats:   p.stats,
+		State: gsiPlayerState{
+			Health:      p.health,
+			Armor:       p.armor,
+			Helmet:      p.helmet,
+			Flashed:     p.flashed,
+			Burning:     p.burning,
+			Money:       p.money,
+			RoundKills:  p.roundKills,
+			RoundKillHS: p.roundKillHS,
+			EquipValue:  p.equipValue(),
+		},
+		Weapons: make(map[string]*gsiWeapon),
+	}
+	if withSteamID {
+		out.SteamID = p.steamID
+		out.Activity = "playing"
+	}
+
+	for i, w := range p.weapons {
+		spec := weaponCatalog[w.name]
+		gw := &gsiWeapon{
+			Name:     w.name,
+			Paintkit: "default",
+			Type:     spec.kind,
+			State:    "holstered",
+		}
+		if w.active {
+			gw.State = "active"
+		}
+		switch spec.kind {
+		case "Knife", "C4":
+		case "Grenade":
+			reserve := w.reserve
+			gw.AmmoReserve = &reserve
+		default:
+			clip, clipMax, reserve := w.clip, spec.clipMax, w.reserve
+			gw.AmmoClip, gw.AmmoClipMax, gw.AmmoReserve = &clip, &clipMax, &reserve
+		}
+		out.Weapons[fmt.Sprintf("weapon_%d", i)] = gw
+	}
+	return out
+}
+
+// payload renders the current state as seen by the simulated client.
+func (m *match) payload() *gsiPayload {
+	mapName := m.opts.Map
+	payload := &gsiPayload{
+		Provider: gsiProvider{
+			Name:      "Counter-Strike: Global Offensive",
+			AppID:     730,
+			Version:   14000,
+			SteamID:   m.players[0].steamID,
+			Timestamp: m.clock.Unix(),
+		},
+		Map: gsiMap{
+			Mode:  "competitive",
+			Name:  mapName,
+			Phase: m.mapPhase,
+			Round: m.round,
+			TeamCT: gsiTeam{
+				Score:                  m.scores["CT"],
+				ConsecutiveRoundLosses: m.losses["CT"],
+				TimeoutsRemaining:      1,
+			},
+			TeamT: gsiTeam{
+				Score:                  m.scores["T"],
+				ConsecutiveRoundLosses: m.losses["T"],
+				TimeoutsRemaining:      1,
+			},
+			RoundWins: m.roundWins,
+		},
+		Round: gsiRound{
+			Phase:   m.roundPhase,
+			WinTeam: m.winTeam,
+			Bomb:    m.roundBomb,
+		},
+		Player:          *m.gsiPlayer(m.watched, true),
+		PhaseCountdowns: m.phaseCountdown(),
+	}
+	if m.mapPhase == "warmup" {
+		payload.Round.Phase = "freezetime"
+	}
+	if m.opts.AuthToken != "" {
+		payload.Auth = &struct {
+			Token string `json:"token"`
+		}{Token: m.opts.AuthToken}
+	}
+
+	// Only spectators receive allplayers and the bomb block
+	if m.opts.AllPlayers {
+		payload.AllPlayers = make(map[string]*gsiPlayer, len(m.players))
+		for _, p := range m.players {
+			payload.AllPlayers[p.steamID] = m.gsiPlayer(p, false)
+		}
+		if m.bomb != nil {
+			payload.Bomb = m.gsiBomb()
+		}
+	}
+
+	return payload
+}
+
+func (m *match) gsiBomb() *gsiBomb {
+	b := m.bomb
+	out := &gsiBomb{State: b.state, Player: b.carrier, Position: "0.00, 0.00, 0.00"}
+	if b.site != "" {
+		out.Position = bombSites[b.site]
+	}
+	switch b.state {
+	case "planting", "defusing":
+		out.Countdown = fmt.Sprintf("%.1f", max(0, b.action.Seconds()))
+	case "planted":
+		out.Countdown = fmt.Sprintf("%.1f", max(0, b.timer.Seconds()))
+	}
+	return out
+}
@@ -0,0 +1,226 @@
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// The types below mirror the JSON CS2 sends to GSI endpoints.
+
+type gsiProvider struct {
+	Name      string `json:"name"`
+	AppID     int    `json:"appid"`
+	Version   int    `json:"version"`
+	SteamID   string `json:"steamid"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+type gsiTeam struct {
+	Score                  int `json:"score"`
+	ConsecutiveRoundLosses int `json:"consecutive_round_losses"`
+	TimeoutsRemaining      int `json:"timeouts_remaining"`
+	MatchesWonThisSeries   int `json:"matches_won_this_series"`
+}
+
+type gsiMap struct {
+	Mode                  string            `json:"mode"`
+	Name                  string            `json:"name"`
+	Phase                 string            `json:"phase"`
+	Round                 int               `json:"round"`
+	TeamCT                gsiTeam           `json:"team_ct"`
+	TeamT                 gsiTeam           `json:"team_t"`
+	NumMatchesToWinSeries int               `json:"num_matches_to_win_series"`
+	RoundWins             map[string]string `json:"round_wins,omitempty"`
+}
+
+type gsiRound struct {
+	Phase   string `json:"phase"`
+	WinTeam string `json:"win_team,omitempty"`
+	Bomb    string `json:"bomb,omitempty"`
+}
+
+type gsiWeapon struct {
+	Name        string `json:"name"`
+	Paintkit    string `json:"paintkit"`
+	Type        string `json:"type"`
+	AmmoClip    *int   `json:"ammo_clip,omitempty"`
+	AmmoClipMax *int   `json:"ammo_clip_max,omitempty"`
+	AmmoReserve *int   `json:"ammo_reserve,omitempty"`
+	State       string `json:"state"`
+}
+
+type gsiPlayerState struct {
+	Health      int  `json:"health"`
+	Armor       int  `json:"armor"`
+	Helmet      bool `json:"helmet"`
+	Flashed     int  `json:"flashed"`
+	Smoked      int  `json:"smoked"`
+	Burning     int  `json:"burning"`
+	Money       int  `json:"money"`
+	RoundKills  int  `json:"round_kills"`
+	RoundKillHS int  `json:"round_killhs"`
+	EquipValue  int  `json:"equip_value"`
+}
+
+type gsiMatchStats struct {
+	Kills   int `json:"kills"`
+	Assists int `json:"assists"`
+	Deaths  int `json:"deaths"`
+	MVPs    int `json:"mvps"`
+	Score   int `json:"score"`
+}
+
+type gsiPlayer struct {
+	SteamID      string                `json:"steamid,omitempty"`
+	Name         string                `json:"name"`
+	ObserverSlot int                   `json:"observer_slot"`
+	Team         string                `json:"team"`
+	Activity     string                `json:"activity,omitempty"`
+	MatchStats   gsiMatchStats         `json:"match_stats"`
+	State        gsiPlayerState        `json:"state"`
+	Weapons      map[string]*gsiWeapon `json:"weapons"`
+}
+
+type gsiBomb struct {
+	State     string `json:"state"`
+	Position  string `json:"position"`
+	Player    string `json:"player,omitempty"`
+	Countdown string `json:"countdown,omitempty"`
+}
+
+type gsiPhaseCountdowns struct {
+	Phase       string `json:"phase"`
+	PhaseEndsIn string `json:"phase_ends_in"`
+}
+
+type gsiPayload struct {
+	Provider        gsiProvider           `json:"provider"`
+	Map             gsiMap                `json:"map"`
+	Round           gsiRound              `json:"round"`
+	Player          gsiPlayer             `json:"player"`
+	AllPlayers      map[string]*gsiPlayer `json:"allplayers,omitempty"`
+	Bomb            *gsiBomb              `json:"bomb,omitempty"`
+	PhaseCountdowns gsiPhaseCountdowns    `json:"phase_countdowns"`
+	Auth            *struct {
+		Token string `json:"token"`
+	} `json:"auth,omitempty"`
+}
+
+// diffedSections are the top level blocks CS2 reports in previously/added.
+var diffedSections = []string{"map", "round", "player", "allplayers", "bomb", "phase_countdowns"}
+
+// encode marshals the payload, adding "previously" and "added" blocks
+// describing what changed since prev, the way CS2 does. prev may be nil.
+func encode(cur *gsiPayload, prev map[string]any) ([]byte, map[string]any, error) {
+	tree, err := toTree(cur)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out := make(map[string]any, len(tree)+2)
+	for k, v := range tree {
+		out[k] = v
+	}
+
+	if prev != nil {
+		previously := make(map[string]any)
+		added := make(map[string]any)
+		for _, section := range diffedSections {
+			p, pOK := prev[section]
+			c, cOK := tree[section]
+			switch {
+			case pOK && cOK:
+				if old, add := diff(p, c); old != nil || add != nil {
+					if old != nil {
+						previously[section] = old
+					}
+					if add != nil {
+						added[section] = add
+					}
+				}
+			case pOK:
+				previously[section] = p
+			case cOK:
+				added[section] = true
+			}
+		}
+		// GSI flags a weapon as added, not the fields it gained
+		if player, ok := added["player"].(map[string]any); ok {
+			if weapons, ok := player["weapons"].(map[string]any); ok {
+				for slot := range weapons {
+					weapons[slot] = true
+				}
+			}
+		}
+		if len(previously) > 0 {
+			out["previously"] = previously
+		}
+		if len(added) > 0 {
+			out["added"] = added
+		}
+	}
+
+	body, err := json.Marshal(out)
+	if err != nil {
+		return nil, nil, err
+	}
+	return body, tree, nil
+}
+
+// diff returns the old values of changed or removed keys, and true for
+// every added key, recursing into nested objects.
+func diff(prev, cur any) (any, any) {
+	pm, pIsMap := prev.(map[string]any)
+	cm, cIsMap := cur.(map[string]any)
+	if !pIsMap || !cIsMap {
+		if reflect.DeepEqual(prev, cur) {
+			return nil, nil
+		}
+		return prev, nil
+	}
+
+	previously := make(map[string]any)
+	added := make(map[string]any)
+	for k, pv := range pm {
+		cv, ok := cm[k]
+		if !ok {
+			previously[k] = pv
+			continue
+		}
+		if old, add := diff(pv, cv); old != nil || add != nil {
+			if old != nil {
+				previously[k] = old
+			}
+			if add != nil {
+				added[k] = add
+			}
+		}
+	}
+	for k := range cm {
+		if _, ok := pm[k]; !ok {
+			added[k] = true
+		}
+	}
+
+	var old, add any
+	if len(previously) > 0 {
+		old = previously
+	}
+	if len(added) > 0 {
+		add = added
+	}
+	return old, add
+}
+
+func toTree(v any) (map[string]any, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	var tree map[string]any
+	if err := json.Unmarshal(raw, &tree); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+	return tree, nil
+}
@@ -0,0 +1,82 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Options configures a simulated match.
+type Options struct {
+	Map        string        // map name, e.g. de_mirage
+	Rounds     int           // max rounds; the match ends when a side wins Rounds/2+1
+	Players    int           // players in the match, split across both sides (2-10)
+	Tick       time.Duration // game time between payloads
+	EventRate  float64       // average combat events per second of live round time
+	SteamID    string        // SteamID of the simulated client's player
+	AuthToken  string        // sent in the auth block if set
+	AllPlayers bool          // include the allplayers block, as for a spectator
+	Seed       int64         // random seed; 0 picks one from the clock
+}
+
+// DefaultOptions returns options for a regular 5v5 competitive match.
+func DefaultOptions() Options {
+	return Options{
+		Map:       "de_mirage",
+		Rounds:    24,
+		Players:   10,
+		Tick:      500 * time.Millisecond,
+		EventRate: 0.3,
+		SteamID:   "76561198000000001",
+	}
+}
+
+// Run simulates one full match, from warmup to gameover, passing each GSI
+// body to send. Between payloads it sleeps Tick divided by speed; a speed of
+// 0 or less sends payloads as fast as possible.
+func Run(ctx context.Context, opts Options, speed float64, send func([]byte) error) (int, error) {
+	if opts.Players < 2 || opts.Players > 10 {
+		return 0, fmt.Errorf("player count must be between 2 and 10, got %d", opts.Players)
+	}
+	if opts.Rounds < 2 {
+		return 0, fmt.Errorf("rounds must be at least 2, got %d", opts.Rounds)
+	}
+	if opts.Tick <= 0 {
+		return 0, fmt.Errorf("tick must be positive")
+	}
+	if opts.Seed == 0 {
+		opts.Seed = time.Now().UnixNano()
+	}
+
+	m := newMatch(opts, rand.New(rand.NewSource(opts.Seed)))
+
+	var prev map[string]any
+	sent := 0
+	for !m.finished() {
+		m.step()
+
+		body, tree, err := encode(m.payload(), prev)
+		if err != nil {
+			return sent, err
+		}
+		prev = tree
+
+		if err := send(body); err != nil {
+			return sent, err
+		}
+		sent++
+
+		if speed > 0 {
+			select {
+			case <-ctx.Done():
+				return sent, ctx.Err()
+			case <-time.After(time.Duration(float64(opts.Tick) / speed)):
+			}
+		} else if err := ctx.Err(); err != nil {
+			return sent, err
+		}
+	}
+
+	return sent, nil
+}